@@ -0,0 +1,161 @@
+package localcache
+
+import "container/list"
+
+// 元素所在的队列
+const (
+	arcT1 = iota // 只访问过一次的常驻元素
+	arcT2        // 访问过多次的常驻元素
+	arcB1        // 从 T1 剔除的幽灵元素, 只保存 key
+	arcB2        // 从 T2 剔除的幽灵元素, 只保存 key
+)
+
+// ARC 淘汰策略
+// 幽灵元素再次写入时说明对应的队列太小, 据此调整 T1 的目标大小 p,
+// 在偏重最近访问和偏重访问频率之间自适应
+type arc struct {
+	capacity   int
+	p          int // T1 的目标大小
+	lists      [4]*list.List
+	nodes      map[interface{}]*list.Element
+	ghostHitB2 bool // 最近一次写入命中了 B2
+}
+
+type arcNode struct {
+	key   interface{}
+	queue int
+}
+
+func newARC(builder *CacheBuilder) *arc {
+	p := &arc{
+		capacity: builder.capacity,
+		nodes:    make(map[interface{}]*list.Element),
+	}
+	for i := range p.lists {
+		p.lists[i] = list.New()
+	}
+	return p
+}
+
+func (p *arc) OnInsert(key interface{}) {
+	p.ghostHitB2 = false
+	elem, ok := p.nodes[key]
+	if !ok {
+		p.push(key, arcT1)
+		p.trimGhosts()
+		return
+	}
+
+	b1, b2 := p.lists[arcB1].Len(), p.lists[arcB2].Len()
+	switch elem.Value.(*arcNode).queue {
+	case arcB1:
+		// 最近访问的元素被剔除得太早, 扩大 T1
+		p.p += maxInt(b2/b1, 1)
+		if limit := p.limit(); p.p > limit {
+			p.p = limit
+		}
+	case arcB2:
+		// 多次访问的元素被剔除得太早, 缩小 T1
+		p.p -= maxInt(b1/b2, 1)
+		if p.p < 0 {
+			p.p = 0
+		}
+		p.ghostHitB2 = true
+	default:
+		// 已经是常驻元素, 按访问处理
+		p.OnAccess(key)
+		return
+	}
+	p.moveTo(elem, arcT2)
+}
+
+func (p *arc) OnAccess(key interface{}) {
+	elem, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	switch elem.Value.(*arcNode).queue {
+	case arcT1, arcT2:
+		p.moveTo(elem, arcT2)
+	}
+}
+
+func (p *arc) OnRemove(key interface{}) {
+	elem, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	switch elem.Value.(*arcNode).queue {
+	case arcT1, arcT2:
+		p.remove(elem)
+	}
+}
+
+// 按照 p 从 T1 或 T2 的尾部剔除, 被剔除的 key 进入对应的幽灵队列
+func (p *arc) Victim() (interface{}, bool) {
+	t1 := p.lists[arcT1].Len()
+	var elem *list.Element
+	if t1 > 0 && (t1 > p.p || (p.ghostHitB2 && t1 == p.p) || p.lists[arcT2].Len() == 0) {
+		elem = p.lists[arcT1].Back()
+		p.moveTo(elem, arcB1)
+	} else if elem = p.lists[arcT2].Back(); elem != nil {
+		p.moveTo(elem, arcB2)
+	} else {
+		return nil, false
+	}
+
+	p.trimGhosts()
+	return elem.Value.(*arcNode).key, true
+}
+
+// 限制幽灵队列的长度, T1+B1 不超过容量, 四个队列合计不超过两倍容量
+func (p *arc) trimGhosts() {
+	limit := p.limit()
+	for p.lists[arcT1].Len()+p.lists[arcB1].Len() > limit && p.lists[arcB1].Len() > 0 {
+		p.remove(p.lists[arcB1].Back())
+	}
+	for p.len() > 2*limit && p.lists[arcB2].Len() > 0 {
+		p.remove(p.lists[arcB2].Back())
+	}
+}
+
+// 容量, 没有设置容量时以当前常驻元素的数量为准
+func (p *arc) limit() int {
+	if p.capacity > 0 {
+		return p.capacity
+	}
+	return p.lists[arcT1].Len() + p.lists[arcT2].Len()
+}
+
+func (p *arc) len() int {
+	n := 0
+	for _, l := range p.lists {
+		n += l.Len()
+	}
+	return n
+}
+
+func (p *arc) push(key interface{}, queue int) {
+	p.nodes[key] = p.lists[queue].PushFront(&arcNode{key: key, queue: queue})
+}
+
+func (p *arc) remove(elem *list.Element) {
+	node := elem.Value.(*arcNode)
+	p.lists[node.queue].Remove(elem)
+	delete(p.nodes, node.key)
+}
+
+// 把元素移动到另一个队列的头部
+func (p *arc) moveTo(elem *list.Element, queue int) {
+	node := elem.Value.(*arcNode)
+	p.lists[node.queue].Remove(elem)
+	node.queue = queue
+	p.nodes[node.key] = p.lists[queue].PushFront(node)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,181 @@
+package localcache
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// compute 对元素的处理
+type computeOp int
+
+const (
+	computeNone   computeOp = iota // 保持不变
+	computeSet                     // 写入新值, 和 Set 一样使用 SetDuration 设置的过期时间
+	computeUpdate                  // 写入新值, 已经存在时保留原来的过期时刻
+	computeDelete                  // 删除
+)
+
+// 在一次加锁内读取 key 当前的值交给 fn, 再按 fn 的返回写入或删除
+// fn 拿到和返回的都是反序列化后的值, 不能在 fn 中操作缓存
+func (c *basicCache) compute(key interface{}, fn func(old interface{}, exists bool) (interface{}, computeOp, error)) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+	now := time.Now()
+
+	c.mu.Lock()
+	var evicted []evictedItem
+	stored, meta, ok := c.store.peek(key)
+	if ok && meta.isDead(now) {
+		item, _ := c.store.remove(key)
+		item.reason = EvictExpired
+		evicted = append(evicted, item)
+		ok = false
+	}
+	// 保留窗口内的旧值只通过 GetOrLoad 返回, 这里和负缓存一样视为不存在
+	exists := ok && !meta.IsExpire(now) && !isNegative(stored)
+
+	var old, value interface{}
+	var err error
+	if exists {
+		old, err = c.decode(context.Background(), stored)
+		err = codecErr(err)
+	}
+	op := computeNone
+	if err == nil {
+		value, op, err = fn(old, exists)
+	}
+
+	var cost int64
+	switch {
+	case err != nil:
+	case op == computeSet || op == computeUpdate:
+		newMeta := c.newMeta(c.defaultExpiration())
+		if op == computeUpdate && exists {
+			newMeta = meta
+		}
+		newMeta.cost = c.costOf(key, value)
+		newMeta.missCost = 1
+		cost = newMeta.cost
+
+		var items []evictedItem
+		value, items, err = c.putLocked(key, value, newMeta)
+		evicted = append(evicted, items...)
+	case op == computeDelete && ok:
+		item, _ := c.store.remove(key)
+		evicted = append(evicted, item)
+	}
+	c.mu.Unlock()
+
+	c.notify(evicted...)
+	if c.flight {
+		if exists {
+			(*c.register).IncrHicCount()
+		} else {
+			(*c.register).IncrMissCount()
+		}
+	}
+	if err != nil || (op != computeSet && op != computeUpdate) {
+		return err
+	}
+
+	if c.flight {
+		(*c.register).AddCost(cost)
+	}
+	if c.addCallback != nil {
+		c.addCallback(context.Background(), key, value)
+	}
+	return nil
+}
+
+// 序列化后写入, 返回序列化后的值, 调用方需持有锁
+func (c *basicCache) putLocked(key, value interface{}, meta itemMeta) (interface{}, []evictedItem, error) {
+	if c.oversized(meta.cost) {
+		return nil, nil, ErrCacheFull
+	}
+	value, err := c.serialize(context.Background(), value)
+	if err != nil {
+		return nil, nil, codecErr(err)
+	}
+
+	evicted, err := c.store.put(key, value, meta)
+	return value, evicted, err
+}
+
+// 不存在时写入, 返回是否写入
+func (c *basicCache) SetIfAbsent(key, value interface{}) (bool, error) {
+	written := false
+	err := c.compute(key, func(old interface{}, exists bool) (interface{}, computeOp, error) {
+		if exists {
+			return nil, computeNone, nil
+		}
+		written = true
+		return value, computeSet, nil
+	})
+	return written && err == nil, err
+}
+
+// 存在时覆盖, 返回是否写入
+func (c *basicCache) Replace(key, value interface{}) (bool, error) {
+	written := false
+	err := c.compute(key, func(old interface{}, exists bool) (interface{}, computeOp, error) {
+		if !exists {
+			return nil, computeNone, nil
+		}
+		written = true
+		return value, computeSet, nil
+	})
+	return written && err == nil, err
+}
+
+// 当前值等于 old 时写入 new, 按 reflect.DeepEqual 比较, 返回是否写入
+func (c *basicCache) CompareAndSwap(key, old, new interface{}) (bool, error) {
+	written := false
+	err := c.compute(key, func(current interface{}, exists bool) (interface{}, computeOp, error) {
+		if !exists || !reflect.DeepEqual(current, old) {
+			return nil, computeNone, nil
+		}
+		written = true
+		return new, computeSet, nil
+	})
+	return written && err == nil, err
+}
+
+// 读取并删除, 不存在时返回 ErrNotFound
+func (c *basicCache) GetAndDelete(key interface{}) (interface{}, error) {
+	var value interface{}
+	found := false
+	err := c.compute(key, func(old interface{}, exists bool) (interface{}, computeOp, error) {
+		if !exists {
+			return nil, computeNone, nil
+		}
+		value, found = old, true
+		return nil, computeDelete, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+// 根据当前值计算新值, fn 返回 keep 为 false 时删除, 返回计算后的值, 删除时为 nil
+// fn 在缓存的锁内执行, 不能在 fn 中操作缓存
+func (c *basicCache) Compute(key interface{}, fn ComputeFunc) (interface{}, error) {
+	var result interface{}
+	err := c.compute(key, func(old interface{}, exists bool) (interface{}, computeOp, error) {
+		value, keep := fn(old, exists)
+		if !keep {
+			return nil, computeDelete, nil
+		}
+		result = value
+		return value, computeSet, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,159 @@
+package localcache
+
+import (
+	"context"
+	"time"
+)
+
+// 批量读取, 所有 key 一起加锁读取, 返回命中的值和未命中的 key, 过期的 key 和负缓存视为未命中
+func (c *basicCache) GetMany(keys []interface{}) (map[interface{}]interface{}, []interface{}) {
+	values, missing, _ := c.getMany(keys)
+	return values, missing
+}
+
+// 批量读取的实现, 另外返回命中负缓存的 key 和记录的错误
+// 存储支持时先在读锁内读取, 只有存在失效的元素时才换成写锁删除
+func (c *basicCache) getMany(keys []interface{}) (map[interface{}]interface{}, []interface{}, map[interface{}]error) {
+	now := time.Now()
+	raw := make([]interface{}, len(keys)) // 和 keys 一一对应, 未命中时为 nil
+	index := make([]int, 0, len(keys))    // 需要在写锁内读取的 key 在 keys 中的位置
+	if c.shared != nil {
+		c.mu.RLock()
+		for i, key := range keys {
+			value, meta, dead := c.shared.getShared(key, now)
+			if dead {
+				index = append(index, i)
+			} else if value != nil && !meta.IsExpire(now) {
+				raw[i] = value
+			}
+		}
+		c.mu.RUnlock()
+	} else {
+		for i := range keys {
+			index = append(index, i)
+		}
+	}
+
+	var evicted []evictedItem
+	if len(index) > 0 {
+		c.mu.Lock()
+		for _, i := range index {
+			value, meta, err := c.store.get(keys[i], now)
+			if err != nil || value == nil {
+				continue
+			}
+			if meta.isDead(now) {
+				evicted = append(evicted, evictedItem{keys[i], value, EvictExpired, meta.cost})
+			} else if !meta.IsExpire(now) {
+				raw[i] = value
+			}
+		}
+		c.mu.Unlock()
+	}
+	c.notify(evicted...)
+
+	// 反序列化在锁外执行
+	values := make(map[interface{}]interface{}, len(keys))
+	var missing []interface{}
+	var failures map[interface{}]error
+	for i, key := range keys {
+		if neg, ok := raw[i].(*negative); ok {
+			if failures == nil {
+				failures = make(map[interface{}]error)
+			}
+			failures[key] = neg.err
+			missing = append(missing, key)
+			continue
+		}
+
+		value, err := c.decode(context.Background(), raw[i])
+		if err != nil || value == nil {
+			missing = append(missing, key)
+			continue
+		}
+		values[key] = value
+	}
+
+	if c.flight {
+		(*c.register).AddHitCount(int32(len(values)))
+		(*c.register).AddMissCount(int32(len(missing) - len(failures)))
+		(*c.register).AddNegativeHitCount(int32(len(failures)))
+	}
+	return values, missing, failures
+}
+
+// 批量写入, 序列化在锁外执行, 写入只加一次锁, 过期时间和 Set 一致
+// 序列化失败时不写入任何元素, 个别元素写入失败时继续写入其他元素并返回第一个错误
+func (c *basicCache) SetMany(items map[interface{}]interface{}) error {
+	type entry struct {
+		key, value interface{}
+		meta       itemMeta
+	}
+	if c.isClosed() {
+		return ErrClosed
+	}
+
+	entries := make([]entry, 0, len(items))
+	for key, value := range items {
+		meta := c.newMeta(c.defaultExpiration())
+		meta.cost = c.costOf(key, value)
+		meta.missCost = 1
+
+		value, err := c.serialize(context.Background(), value)
+		if err != nil {
+			return codecErr(err)
+		}
+		entries = append(entries, entry{key, value, meta})
+	}
+
+	var evicted []evictedItem
+	var firstErr error
+	var cost int64
+	written := entries[:0]
+
+	c.mu.Lock()
+	for _, e := range entries {
+		var items []evictedItem
+		err := ErrCacheFull
+		if !c.oversized(e.meta.cost) {
+			items, err = c.store.put(e.key, e.value, e.meta)
+		}
+		evicted = append(evicted, items...)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		cost += e.meta.cost
+		written = append(written, e)
+	}
+	c.mu.Unlock()
+
+	c.notify(evicted...)
+	if c.flight {
+		(*c.register).AddCost(cost)
+	}
+	if c.addCallback != nil {
+		for _, e := range written {
+			c.addCallback(context.Background(), e.key, e.value)
+		}
+	}
+	return firstErr
+}
+
+// 批量删除, 只加一次锁, 返回删除的数量
+func (c *basicCache) RemoveMany(keys []interface{}) int {
+	var evicted []evictedItem
+
+	c.mu.Lock()
+	for _, key := range keys {
+		if item, ok := c.store.remove(key); ok {
+			evicted = append(evicted, item)
+		}
+	}
+	c.mu.Unlock()
+
+	c.notify(evicted...)
+	return len(evicted)
+}
@@ -0,0 +1,81 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"testing"
+	"time"
+)
+
+func TestARC(t *testing.T) {
+	r := localcache.CreateRegister()
+	cache := localcache.Create().
+		Tp(localcache.ARC).
+		Capacity(4).
+		OpenFlight(&r).
+		SerializeFunc(localcache.DefaultSerializeFunc).
+		DeserializeFunc(localcache.DefaultDeserializeFunc).
+		Build()
+
+	// a 和 b 被多次访问, 进入 T2
+	cache.Set("a", "aa")
+	cache.Set("b", "bb")
+	cache.Get("a")
+	cache.Get("b")
+
+	// 只访问一次的 key 不应该把 a 和 b 挤出去
+	for _, key := range []string{"c", "d", "e", "f", "g"} {
+		cache.Set(key, key)
+	}
+
+	if !cache.Has("a") || !cache.Has("b") {
+		t.Error("frequent keys should be kept")
+	}
+	if cache.KeyCount() != 4 {
+		t.Errorf("key count %d", cache.KeyCount())
+	}
+
+	value, _ := cache.Get("a")
+	if value != "aa" {
+		t.Errorf("value %v", value)
+	}
+	fmt.Println(cache.GetAll(), r.HitCount())
+}
+
+func TestARCExpire(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.ARC).
+		SetDuration(time.Millisecond).
+		Build()
+
+	cache.Set("a", "aa")
+	time.Sleep(time.Millisecond * 2)
+	if value, _ := cache.Get("a"); value != nil {
+		t.Errorf("expired value %v", value)
+	}
+}
+
+// 先是一段只看最近访问的负载, 再切换到重复访问的负载, 两段都不应该退化
+func TestARCAdaptive(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.ARC).
+		Capacity(100).
+		Build()
+
+	hits := 0
+	for i := 0; i < 10000; i++ {
+		key := i % 150
+		if i > 5000 {
+			key = i % 80
+		}
+		if v, _ := cache.Get(key); v != nil {
+			hits++
+		} else {
+			cache.Set(key, key)
+		}
+	}
+	if hits < 4000 {
+		t.Errorf("hits %d", hits)
+	}
+	fmt.Println("arc hits", hits)
+}
@@ -0,0 +1,151 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAtomic(t *testing.T) {
+	for _, tp := range cacheTypes {
+		var removed int32
+		r := localcache.CreateRegister()
+		cache := localcache.Create().
+			Tp(tp).
+			OpenFlight(&r).
+			SerializeFunc(localcache.DefaultSerializeFunc).
+			DeserializeFunc(localcache.DefaultDeserializeFunc).
+			EvictionCallback(func(key, value interface{}, reason localcache.EvictReason) {
+				if reason == localcache.EvictRemoved {
+					atomic.AddInt32(&removed, 1)
+				}
+			}).
+			Build()
+
+		if ok, _ := cache.Replace("a", "aa"); ok {
+			t.Errorf("%s: replace missing key", tp)
+		}
+		if ok, _ := cache.SetIfAbsent("a", "aa"); !ok {
+			t.Errorf("%s: set absent key", tp)
+		}
+		if ok, _ := cache.SetIfAbsent("a", "bb"); ok {
+			t.Errorf("%s: set present key", tp)
+		}
+		if ok, _ := cache.CompareAndSwap("a", "bb", "cc"); ok {
+			t.Errorf("%s: swap with wrong old value", tp)
+		}
+		if ok, _ := cache.CompareAndSwap("a", "aa", "cc"); !ok {
+			t.Errorf("%s: swap with old value", tp)
+		}
+		if ok, _ := cache.Replace("a", "dd"); !ok {
+			t.Errorf("%s: replace present key", tp)
+		}
+
+		value, err := cache.GetAndDelete("a")
+		if value != "dd" || err != nil || cache.Has("a") {
+			t.Errorf("%s: get and delete %v %v", tp, value, err)
+		}
+		if _, err := cache.GetAndDelete("a"); err != localcache.KeyNotFoundError {
+			t.Errorf("%s: get and delete missing key %v", tp, err)
+		}
+
+		// 删除
+		cache.Set("b", "bb")
+		if value, _ := cache.Compute("b", func(old interface{}, exists bool) (interface{}, bool) {
+			return nil, false
+		}); value != nil || cache.Has("b") {
+			t.Errorf("%s: compute delete", tp)
+		}
+
+		if removed != 2 || r.HitCount() != 6 || r.MissCount() != 3 || r.TotalCost() != 0 {
+			t.Errorf("%s: removed %d, hit %d, miss %d, cost %d", tp, removed, r.HitCount(), r.MissCount(), r.TotalCost())
+		}
+	}
+}
+
+func TestAtomicExpire(t *testing.T) {
+	for _, tp := range cacheTypes {
+		var expired int32
+		cache := localcache.Create().
+			Tp(tp).
+			SetDuration(time.Millisecond).
+			EvictionCallback(func(key, value interface{}, reason localcache.EvictReason) {
+				if reason == localcache.EvictExpired {
+					atomic.AddInt32(&expired, 1)
+				}
+			}).
+			Build()
+
+		cache.Set("a", "aa")
+		time.Sleep(time.Millisecond * 2)
+
+		// 过期的 key 视为不存在, 新值同样使用 SetDuration 的过期时间
+		if ok, _ := cache.SetIfAbsent("a", "bb"); !ok || expired != 1 {
+			t.Errorf("%s: set expired key, expired %d", tp, expired)
+		}
+		time.Sleep(time.Millisecond * 2)
+		if ok, _ := cache.Replace("a", "cc"); ok {
+			t.Errorf("%s: replace expired key", tp)
+		}
+	}
+}
+
+// 并发 Compute 不会丢失更新
+func TestCompute(t *testing.T) {
+	for _, tp := range cacheTypes {
+		cache := localcache.Create().
+			Tp(tp).
+			Build()
+
+		var wins int32
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if ok, _ := cache.SetIfAbsent("lock", 1); ok {
+					atomic.AddInt32(&wins, 1)
+				}
+				for i := 0; i < 100; i++ {
+					cache.Compute("count", func(old interface{}, exists bool) (interface{}, bool) {
+						if !exists {
+							return 1, true
+						}
+						return old.(int) + 1, true
+					})
+				}
+			}()
+		}
+		wg.Wait()
+
+		value, _ := cache.Get("count")
+		if value != 800 || wins != 1 {
+			t.Errorf("%s: count %v, wins %d", tp, value, wins)
+		}
+	}
+	fmt.Println("compute ok")
+}
+
+func TestGenericCompute(t *testing.T) {
+	cache := localcache.CreateGeneric[string, int]().
+		Tp(localcache.LRU).
+		Build()
+
+	for i := 0; i < 3; i++ {
+		cache.Compute("a", func(old int, exists bool) (int, bool) {
+			return old + 1, true
+		})
+	}
+	if value, _ := cache.Get("a"); value != 3 {
+		t.Errorf("value %d", value)
+	}
+	if ok, _ := cache.CompareAndSwap("a", 3, 4); !ok {
+		t.Error("swap")
+	}
+	if value, err := cache.GetAndDelete("a"); value != 4 || err != nil {
+		t.Errorf("get and delete %d %v", value, err)
+	}
+}
@@ -0,0 +1,128 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatch(t *testing.T) {
+	builders := map[string]*localcache.CacheBuilder{
+		"shards": localcache.Create().Tp(localcache.LRU).Shards(4),
+	}
+	for _, tp := range cacheTypes {
+		builders[tp] = localcache.Create().Tp(tp)
+	}
+
+	for name, builder := range builders {
+		var removed int32
+		r := localcache.CreateRegister()
+		cache := builder.
+			Capacity(1000).
+			OpenFlight(&r).
+			EvictionCallback(func(key, value interface{}, reason localcache.EvictReason) {
+				if reason == localcache.EvictRemoved {
+					atomic.AddInt32(&removed, 1)
+				}
+			}).
+			Build()
+
+		items := make(map[interface{}]interface{})
+		keys := make([]interface{}, 0, 150)
+		for i := 0; i < 150; i++ {
+			if i < 100 {
+				items[i] = i * 10
+			}
+			keys = append(keys, i)
+		}
+		if err := cache.SetMany(items); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+
+		values, missing := cache.GetMany(keys)
+		if len(values) != 100 || len(missing) != 50 || values[7] != 70 || missing[0] != 100 {
+			t.Errorf("%s: %d values, missing %v", name, len(values), missing)
+		}
+		if r.HitCount() != 100 || r.MissCount() != 50 || r.TotalCost() != 100 {
+			t.Errorf("%s: hit %d, miss %d, cost %d", name, r.HitCount(), r.MissCount(), r.TotalCost())
+		}
+
+		if n := cache.RemoveMany(keys[:30]); n != 30 || removed != 30 || cache.KeyCount() != 70 {
+			t.Errorf("%s: removed %d, callbacks %d, key count %d", name, n, removed, cache.KeyCount())
+		}
+	}
+}
+
+func TestGetManyExpire(t *testing.T) {
+	for _, tp := range []string{localcache.SIMPLE, localcache.CLOCK, localcache.LRU} {
+		cache := localcache.Create().
+			Tp(tp).
+			SerializeFunc(localcache.DefaultSerializeFunc).
+			DeserializeFunc(localcache.DefaultDeserializeFunc).
+			Build()
+
+		cache.SetMany(map[interface{}]interface{}{"a": "aa", "b": "bb"})
+		cache.SetWithTTL("c", "cc", time.Millisecond)
+		time.Sleep(time.Millisecond * 2)
+
+		// 过期的 key 被删除
+		values, missing := cache.GetMany([]interface{}{"a", "b", "c"})
+		if len(values) != 2 || values["a"] != "aa" || len(missing) != 1 || missing[0] != "c" || cache.KeyCount() != 2 {
+			t.Errorf("%s: %v %v, key count %d", tp, values, missing, cache.KeyCount())
+		}
+		fmt.Println(values, missing)
+	}
+}
+
+func TestGenericBatch(t *testing.T) {
+	cache := localcache.CreateGeneric[string, int]().
+		Tp(localcache.LRU).
+		Build()
+
+	cache.SetMany(map[string]int{"a": 1, "b": 2})
+	values, missing := cache.GetMany([]string{"a", "b", "c"})
+	if values["b"] != 2 || len(missing) != 1 || missing[0] != "c" {
+		t.Error(values, missing)
+	}
+	if cache.RemoveMany([]string{"a", "c"}) != 1 {
+		t.Error("remove many")
+	}
+}
+
+func benchmarkKeys(n int) []interface{} {
+	keys := make([]interface{}, n)
+	for i := range keys {
+		keys[i] = i
+	}
+	return keys
+}
+
+func BenchmarkGetLoop(b *testing.B) {
+	cache := localcache.Create().Tp(localcache.LRU).Capacity(1000).Build()
+	keys := benchmarkKeys(200)
+	for _, key := range keys {
+		cache.Set(key, key)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, key := range keys {
+			cache.Get(key)
+		}
+	}
+}
+
+func BenchmarkGetMany(b *testing.B) {
+	cache := localcache.Create().Tp(localcache.LRU).Capacity(1000).Build()
+	keys := benchmarkKeys(200)
+	for _, key := range keys {
+		cache.Set(key, key)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.GetMany(keys)
+	}
+}
@@ -0,0 +1,39 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCleanupInterval(t *testing.T) {
+	for _, tp := range []string{localcache.SIMPLE, localcache.LRU, localcache.LFU} {
+		var expired int32
+		cache := localcache.Create().
+			Tp(tp).
+			SetDuration(time.Millisecond * 2).
+			CleanupInterval(time.Millisecond * 5).
+			ExpireFunc(func() {
+				atomic.AddInt32(&expired, 1)
+			}).
+			Build()
+
+		cache.Set("a", "aa")
+		cache.Set("b", "bb")
+		cache.SetWithTTL("c", "cc", 0)
+
+		time.Sleep(time.Millisecond * 20)
+		cache.Close()
+
+		// 没有读取过期的 key, 也应该被后台清理掉
+		if cache.KeyCount() != 1 {
+			t.Errorf("%s: key count %d", tp, cache.KeyCount())
+		}
+		if atomic.LoadInt32(&expired) != 2 {
+			t.Errorf("%s: expired %d", tp, expired)
+		}
+		fmt.Println(tp, cache.KeyCount())
+	}
+}
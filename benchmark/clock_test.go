@@ -0,0 +1,129 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.CLOCK).
+		Capacity(3).
+		Build()
+
+	cache.Set("a", "aa")
+	cache.Set("b", "bb")
+	cache.Set("c", "cc")
+
+	// a 被访问过, 指针扫过时只清除标记, 剔除 b
+	cache.Get("a")
+	cache.Set("d", "dd")
+
+	if !cache.Has("a") || cache.Has("b") {
+		t.Error(cache.GetAll())
+	}
+	if cache.KeyCount() != 3 {
+		t.Errorf("key count %d", cache.KeyCount())
+	}
+}
+
+func TestClockPro(t *testing.T) {
+	clockPro := hotKeysAfterScan(localcache.CLOCKPRO, nil)
+	clock := hotKeysAfterScan(localcache.CLOCK, nil)
+
+	if clockPro != 50 {
+		t.Errorf("clockpro keeps %d hot keys", clockPro)
+	}
+	fmt.Println("clockpro", clockPro, "clock", clock)
+
+	// 测试期内再次写入的 key 直接成为热页面
+	reload := hotKeysAfterReload(localcache.Create().Tp(localcache.CLOCKPRO))
+	if reload != 20 {
+		t.Errorf("clockpro keeps %d reloaded keys", reload)
+	}
+}
+
+func TestClockExpire(t *testing.T) {
+	for _, tp := range []string{localcache.CLOCK, localcache.CLOCKPRO} {
+		cache := localcache.Create().
+			Tp(tp).
+			Capacity(10).
+			SetDuration(time.Millisecond).
+			Build()
+
+		cache.Set("a", "aa")
+		time.Sleep(time.Millisecond * 2)
+		if value, _ := cache.Get("a"); value != nil {
+			t.Errorf("%s expired value %v", tp, value)
+		}
+
+		for i := 0; i < 100; i++ {
+			cache.Set(i, i)
+			cache.Remove(i - 5)
+		}
+		if cache.KeyCount() != 5 {
+			t.Errorf("%s key count %d", tp, cache.KeyCount())
+		}
+	}
+}
+
+// 命中只需要读锁, 和写入并发执行
+func TestClockConcurrent(t *testing.T) {
+	for _, tp := range []string{localcache.CLOCK, localcache.CLOCKPRO} {
+		cache := localcache.Create().
+			Tp(tp).
+			Capacity(100).
+			SetDuration(time.Millisecond * 5).
+			Build()
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 2000; i++ {
+					key := (i * (g + 1)) % 300
+					if v, _ := cache.Get(key); v == nil {
+						cache.Set(key, key)
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		if cache.KeyCount() > 100 {
+			t.Errorf("%s key count %d", tp, cache.KeyCount())
+		}
+	}
+}
+
+func benchmarkParallelGet(b *testing.B, tp string) {
+	cache := localcache.Create().
+		Tp(tp).
+		Capacity(1000).
+		Build()
+	for i := 0; i < 1000; i++ {
+		cache.Set(strconv.Itoa(i), i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			cache.Get(strconv.Itoa(i % 1000))
+			i++
+		}
+	})
+}
+
+func BenchmarkParallelGetLRU(b *testing.B) {
+	benchmarkParallelGet(b, localcache.LRU)
+}
+
+func BenchmarkParallelGetClock(b *testing.B) {
+	benchmarkParallelGet(b, localcache.CLOCK)
+}
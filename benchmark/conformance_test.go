@@ -0,0 +1,178 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"localcache"
+	"testing"
+	"time"
+)
+
+var errCodec = errors.New("bad bytes")
+
+// 所有缓存类型共用的错误约定
+func TestConformance(t *testing.T) {
+	builders := map[string]func() *localcache.CacheBuilder{
+		"shards": func() *localcache.CacheBuilder { return localcache.Create().Tp(localcache.LRU).Shards(4) },
+	}
+	for _, tp := range cacheTypes {
+		tp := tp
+		builders[tp] = func() *localcache.CacheBuilder { return localcache.Create().Tp(tp) }
+	}
+
+	for name, builder := range builders {
+		t.Run(name, func(t *testing.T) {
+			conformMiss(t, builder())
+			conformExpired(t, builder())
+			conformCodec(t, builder())
+			conformClosed(t, builder())
+			conformCounter(t, builder())
+		})
+	}
+}
+
+func conformMiss(t *testing.T, builder *localcache.CacheBuilder) {
+	r := localcache.CreateRegister()
+	cache := builder.OpenFlight(&r).Build()
+
+	if value, err := cache.Get("a"); value != nil || !errors.Is(err, localcache.ErrNotFound) {
+		t.Errorf("get: %v %v", value, err)
+	}
+	if _, err := cache.GetCtx(context.Background(), "a"); !errors.Is(err, localcache.ErrNotFound) {
+		t.Errorf("get ctx: %v", err)
+	}
+	if err := cache.Remove("a"); !errors.Is(err, localcache.ErrNotFound) {
+		t.Errorf("remove: %v", err)
+	}
+	if _, err := cache.GetAndDelete("a"); !errors.Is(err, localcache.ErrNotFound) {
+		t.Errorf("get and delete: %v", err)
+	}
+	if ok, err := cache.Replace("a", "aa"); ok || err != nil {
+		t.Errorf("replace: %v %v", ok, err)
+	}
+	if r.MissCount() != 4 || r.HitCount() != 0 {
+		t.Errorf("hit %d, miss %d", r.HitCount(), r.MissCount())
+	}
+
+	cache.Set("a", "aa")
+	if value, err := cache.Get("a"); value != "aa" || err != nil {
+		t.Errorf("get: %v %v", value, err)
+	}
+	if err := cache.Remove("a"); err != nil {
+		t.Errorf("remove: %v", err)
+	}
+}
+
+func conformExpired(t *testing.T, builder *localcache.CacheBuilder) {
+	cache := builder.Build()
+
+	cache.SetWithTTL("a", "aa", time.Millisecond)
+	cache.SetWithTTL("b", "bb", time.Millisecond)
+	time.Sleep(time.Millisecond * 2)
+
+	value, err := cache.Get("a")
+	if value != nil || !errors.Is(err, localcache.ErrExpired) || !errors.Is(err, localcache.ErrNotFound) {
+		t.Errorf("get: %v %v", value, err)
+	}
+	// 过期的 key 读取时被删除, 之后就是不存在
+	if _, err := cache.Get("a"); !errors.Is(err, localcache.ErrNotFound) || errors.Is(err, localcache.ErrExpired) {
+		t.Errorf("get again: %v", err)
+	}
+	if _, err := cache.GetAndDelete("b"); !errors.Is(err, localcache.ErrNotFound) {
+		t.Errorf("get and delete: %v", err)
+	}
+}
+
+func conformCodec(t *testing.T, builder *localcache.CacheBuilder) {
+	cache := builder.
+		SerializeFunc(func(value interface{}) (interface{}, error) {
+			if value == "bad" {
+				return nil, errCodec
+			}
+			return value, nil
+		}).
+		DeserializeFunc(func(value interface{}) (interface{}, error) {
+			if value == "corrupt" {
+				return nil, errCodec
+			}
+			return value, nil
+		}).
+		Build()
+
+	if err := cache.Set("a", "bad"); !errors.Is(err, localcache.ErrCodec) || !errors.Is(err, errCodec) {
+		t.Errorf("set: %v", err)
+	}
+	if err := cache.SetMany(map[interface{}]interface{}{"a": "bad"}); !errors.Is(err, localcache.ErrCodec) {
+		t.Errorf("set many: %v", err)
+	}
+	if _, err := cache.SetIfAbsent("a", "bad"); !errors.Is(err, localcache.ErrCodec) {
+		t.Errorf("set if absent: %v", err)
+	}
+
+	cache.Set("b", "corrupt")
+	if value, err := cache.Get("b"); value != nil || !errors.Is(err, localcache.ErrCodec) || !errors.Is(err, errCodec) {
+		t.Errorf("get: %v %v", value, err)
+	}
+	if _, err := cache.GetAndDelete("b"); !errors.Is(err, localcache.ErrCodec) {
+		t.Errorf("get and delete: %v", err)
+	}
+}
+
+func conformClosed(t *testing.T, builder *localcache.CacheBuilder) {
+	cache := builder.Build()
+	cache.Set("a", "aa")
+	cache.Close()
+	cache.Close()
+
+	if err := cache.Set("b", "bb"); !errors.Is(err, localcache.ErrClosed) {
+		t.Errorf("set: %v", err)
+	}
+	if _, err := cache.Get("a"); !errors.Is(err, localcache.ErrClosed) {
+		t.Errorf("get: %v", err)
+	}
+	if err := cache.Remove("a"); !errors.Is(err, localcache.ErrClosed) {
+		t.Errorf("remove: %v", err)
+	}
+	if _, err := cache.IncrBy("n", 1); !errors.Is(err, localcache.ErrClosed) {
+		t.Errorf("incr: %v", err)
+	}
+	if err := cache.SetMany(map[interface{}]interface{}{"b": "bb"}); !errors.Is(err, localcache.ErrClosed) {
+		t.Errorf("set many: %v", err)
+	}
+	if _, err := cache.GetManyOrLoad(context.Background(), []interface{}{"a"}); !errors.Is(err, localcache.ErrClosed) {
+		t.Errorf("get many or load: %v", err)
+	}
+	// 不返回 error 的方法照常执行
+	if cache.KeyCount() != 1 {
+		t.Errorf("key count %d", cache.KeyCount())
+	}
+}
+
+func conformCounter(t *testing.T, builder *localcache.CacheBuilder) {
+	cache := builder.Build()
+	cache.Set("a", "aa")
+	cache.Set("b", int8(127))
+
+	if _, err := cache.IncrBy("a", 1); !errors.Is(err, localcache.ErrValueType) {
+		t.Errorf("incr string: %v", err)
+	}
+	if _, err := cache.IncrBy("b", 1); !errors.Is(err, localcache.ErrOverflow) {
+		t.Errorf("incr int8: %v", err)
+	}
+}
+
+func TestConformanceCacheFull(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.SIMPLE).
+		Capacity(1).
+		Overflow(localcache.OverflowReject).
+		Build()
+
+	cache.Set("a", "aa")
+	if err := cache.Set("b", "bb"); !errors.Is(err, localcache.ErrCacheFull) {
+		t.Error(err)
+	}
+	if localcache.KeyNotFoundError != localcache.ErrNotFound {
+		t.Error("KeyNotFoundError should stay an alias of ErrNotFound")
+	}
+}
@@ -0,0 +1,229 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"localcache"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type traceKey struct{}
+
+func TestContextCancel(t *testing.T) {
+	for _, tp := range cacheTypes {
+		cache := localcache.Create().
+			Tp(tp).
+			Build()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := cache.SetCtx(ctx, "a", "aa"); err != context.Canceled || cache.Has("a") {
+			t.Errorf("%s: set %v", tp, err)
+		}
+		cache.Set("a", "aa")
+		if _, err := cache.GetCtx(ctx, "a"); err != context.Canceled {
+			t.Errorf("%s: get %v", tp, err)
+		}
+		if value, err := cache.GetCtx(context.Background(), "a"); value != "aa" || err != nil {
+			t.Errorf("%s: get %v %v", tp, value, err)
+		}
+	}
+}
+
+// 请求范围的值传给序列化、反序列化和回调
+func TestContextHooks(t *testing.T) {
+	var mu sync.Mutex
+	traces := make(map[string]interface{})
+	trace := func(hook string, ctx context.Context) {
+		mu.Lock()
+		traces[hook] = ctx.Value(traceKey{})
+		mu.Unlock()
+	}
+
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		Capacity(1).
+		SerializeCtxFunc(func(ctx context.Context, value interface{}) (interface{}, error) {
+			trace("serialize", ctx)
+			return value, nil
+		}).
+		DeserializeCtxFunc(func(ctx context.Context, value interface{}) (interface{}, error) {
+			trace("deserialize", ctx)
+			return value, nil
+		}).
+		AddCtxCallback(func(ctx context.Context, key, value interface{}) {
+			trace("add", ctx)
+		}).
+		EvictionCtxCallback(func(ctx context.Context, key, value interface{}, reason localcache.EvictReason) {
+			trace("evict", ctx)
+		}).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			trace("load", ctx)
+			return "bb", 0, nil
+		}).
+		Build()
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "req-1")
+	cache.SetCtx(ctx, "a", "aa")
+	cache.GetCtx(ctx, "a")
+	cache.GetOrLoad(ctx, "b") // a capacity
+
+	fmt.Println(traces)
+	for _, hook := range []string{"serialize", "deserialize", "add", "evict", "load"} {
+		if traces[hook] != "req-1" {
+			t.Errorf("%s: %v", hook, traces[hook])
+		}
+	}
+
+	// 不带 ctx 的方法使用 context.Background()
+	cache.Set("c", "cc")
+	if traces["serialize"] != nil {
+		t.Error(traces)
+	}
+}
+
+func TestGetOrLoadDeadline(t *testing.T) {
+	loaded := make(chan struct{})
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			// 不响应取消的慢加载
+			time.Sleep(time.Millisecond * 100)
+			defer close(loaded)
+			return "aa", 0, nil
+		}).
+		Build()
+
+	// 共享同一次加载的调用方按自己的 ctx 返回
+	var wg sync.WaitGroup
+	for _, timeout := range []time.Duration{time.Millisecond * 10, time.Millisecond * 20} {
+		wg.Add(1)
+		go func(timeout time.Duration) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			start := time.Now()
+			if _, err := cache.GetOrLoad(ctx, "a"); err != context.DeadlineExceeded || time.Since(start) > time.Millisecond*60 {
+				t.Errorf("%v after %v", err, time.Since(start))
+			}
+		}(timeout)
+	}
+	wg.Wait()
+
+	// 加载完成后仍然写入缓存
+	<-loaded
+	time.Sleep(time.Millisecond * 5)
+	if value, _ := cache.Get("a"); value != "aa" {
+		t.Error(value)
+	}
+}
+
+// 第一个调用方取消不影响等待同一次加载的其他调用方
+func TestGetOrLoadSharedCancel(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			select {
+			case <-time.After(time.Millisecond * 50):
+				return "aa", 0, nil
+			case <-ctx.Done():
+				return nil, 0, ctx.Err()
+			}
+		}).
+		Build()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := cache.GetOrLoad(ctx, "a")
+		errs <- err
+	}()
+	time.Sleep(time.Millisecond * 5)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if value, err := cache.GetOrLoad(context.Background(), "a"); value != "aa" || err != nil {
+			t.Errorf("second: %v %v", value, err)
+		}
+	}()
+	time.Sleep(time.Millisecond * 5)
+	cancel()
+
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("first: %v", err)
+	}
+	<-done
+}
+
+// 批量加载收到开启批次的调用方的 ctx 中的值
+func TestBatchLoaderContextValue(t *testing.T) {
+	values := make(chan interface{}, 1)
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		BatchLoader(func(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error) {
+			values <- ctx.Value(traceKey{})
+			return map[interface{}]interface{}{keys[0]: "aa"}, ctx.Err()
+		}).
+		Build()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), traceKey{}, "req-1"))
+	defer cancel()
+	if value, err := cache.GetOrLoad(ctx, "a"); value != "aa" || err != nil {
+		t.Errorf("%v %v", value, err)
+	}
+	if value := <-values; value != "req-1" {
+		t.Error(value)
+	}
+}
+
+// 所有调用方都放弃等待后取消加载, 之后的调用方重新加载
+func TestGetOrLoadCancelLoader(t *testing.T) {
+	var loads int32
+	cancelled := make(chan struct{}, 1)
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		ErrorBackoff(time.Minute).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			if atomic.AddInt32(&loads, 1) > 1 {
+				return "aa", 0, nil
+			}
+			select {
+			case <-time.After(time.Second):
+				return "slow", 0, nil
+			case <-ctx.Done():
+				cancelled <- struct{}{}
+				return nil, 0, ctx.Err()
+			}
+		}).
+		Build()
+
+	var wg sync.WaitGroup
+	for _, timeout := range []time.Duration{time.Millisecond * 5, time.Millisecond * 10} {
+		wg.Add(1)
+		go func(timeout time.Duration) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if _, err := cache.GetOrLoad(ctx, "a"); err != context.DeadlineExceeded {
+				t.Error(err)
+			}
+		}(timeout)
+	}
+	wg.Wait()
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Millisecond * 100):
+		t.Fatal("loader not cancelled")
+	}
+	// 取消导致的失败不会被记住
+	if value, err := cache.GetOrLoad(context.Background(), "a"); value != "aa" || err != nil {
+		t.Errorf("%v %v", value, err)
+	}
+}
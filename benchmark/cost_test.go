@@ -0,0 +1,64 @@
+package benchmark
+
+import (
+	"errors"
+	"fmt"
+	"localcache"
+	"testing"
+)
+
+func TestMaxCost(t *testing.T) {
+	for _, tp := range []string{localcache.LRU, localcache.LFU} {
+		r := localcache.CreateRegister()
+		cache := localcache.Create().
+			Tp(tp).
+			Capacity(0).
+			MaxCost(100).
+			CostFunc(func(key, value interface{}) int64 {
+				return int64(len(value.(string)))
+			}).
+			OpenFlight(&r).
+			Build()
+
+		cache.Set("a", string(make([]byte, 40)))
+		cache.Set("b", string(make([]byte, 40)))
+		cache.Get("b")
+		cache.Set("c", string(make([]byte, 40)))
+
+		// 总成本超过 100, 需要剔除 a
+		if cache.Has("a") || !cache.Has("b") || !cache.Has("c") {
+			t.Errorf("%s: a should be evicted", tp)
+		}
+		if cache.TotalCost() != 80 || r.TotalCost() != 80 {
+			t.Errorf("%s: total cost %d, register %d", tp, cache.TotalCost(), r.TotalCost())
+		}
+
+		// 单个元素超过上限时不会留在缓存中
+		cache.SetWithCost("d", "dd", 200)
+		if cache.Has("d") || cache.TotalCost() > 100 {
+			t.Errorf("%s: d should be rejected, total cost %d", tp, cache.TotalCost())
+		}
+		fmt.Println(tp, cache.TotalCost(), r.TotalCost())
+	}
+}
+
+// 成本超过 MaxCost 的元素直接拒绝, 不会剔除已有的元素
+func TestMaxCostOversized(t *testing.T) {
+	for _, tp := range cacheTypes {
+		cache := localcache.Create().
+			Tp(tp).
+			MaxCost(10).
+			Build()
+
+		cache.SetWithCost("a", "aa", 5)
+		if err := cache.SetWithCost("b", "bb", 50); !errors.Is(err, localcache.ErrCacheFull) {
+			t.Errorf("%s: set %v", tp, err)
+		}
+		if err := cache.SetMany(map[interface{}]interface{}{"c": "cc"}); err != nil {
+			t.Errorf("%s: set many %v", tp, err)
+		}
+		if !cache.Has("a") || cache.Has("b") || cache.TotalCost() != 6 {
+			t.Errorf("%s: %v, total cost %d", tp, cache.GetAll(), cache.TotalCost())
+		}
+	}
+}
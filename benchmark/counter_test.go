@@ -0,0 +1,133 @@
+package benchmark
+
+import (
+	"localcache"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIncrBy(t *testing.T) {
+	for _, tp := range cacheTypes {
+		cache := localcache.Create().
+			Tp(tp).
+			Build()
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					cache.IncrBy("hits", 2)
+					cache.DecrBy("hits", 1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		value, _ := cache.Get("hits")
+		if value != int64(800) {
+			t.Errorf("%s: hits %v", tp, value)
+		}
+	}
+}
+
+func TestIncrByTTL(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		SetDuration(time.Hour).
+		Build()
+
+	// 已经存在的计数保留原来的过期时刻
+	cache.SetWithTTL("a", 1, time.Millisecond*20)
+	if n, err := cache.IncrBy("a", 1); n != 2 || err != nil {
+		t.Errorf("incr %d %v", n, err)
+	}
+	time.Sleep(time.Millisecond * 30)
+	if cache.Has("a") {
+		t.Error("counter should expire")
+	}
+
+	// 过期后重新从 delta 开始
+	if n, _ := cache.IncrBy("a", 5); n != 5 {
+		t.Errorf("incr expired counter %d", n)
+	}
+}
+
+func TestIncrByType(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.SIMPLE).
+		Build()
+
+	// 保持原来的类型
+	cache.Set("int", 1)
+	cache.IncrBy("int", 1)
+	if value, _ := cache.Get("int"); value != 2 {
+		t.Errorf("int %#v", value)
+	}
+	cache.Set("str", "41")
+	cache.IncrBy("str", 1)
+	if value, _ := cache.Get("str"); value != "42" {
+		t.Errorf("str %#v", value)
+	}
+
+	cache.Set("word", "abc")
+	if _, err := cache.IncrBy("word", 1); err != localcache.ErrValueType {
+		t.Errorf("word %v", err)
+	}
+	cache.Set("small", int8(127))
+	if _, err := cache.IncrBy("small", 1); err != localcache.ErrOverflow {
+		t.Errorf("small %v", err)
+	}
+	cache.Set("big", int64(math.MaxInt64))
+	if _, err := cache.IncrBy("big", 1); err != localcache.ErrOverflow {
+		t.Errorf("big %v", err)
+	}
+	if value, _ := cache.Get("big"); value != int64(math.MaxInt64) {
+		t.Errorf("big %v", value)
+	}
+}
+
+func TestIncrByCodec(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		SerializeFunc(localcache.DefaultSerializeFunc).
+		DeserializeFunc(localcache.DefaultDeserializeFunc).
+		Build()
+
+	cache.IncrBy("a", 10)
+	if n, err := cache.DecrBy("a", 3); n != 7 || err != nil {
+		t.Errorf("decr %d %v", n, err)
+	}
+	if value, _ := cache.Get("a"); value != "7" {
+		t.Errorf("value %#v", value)
+	}
+}
+
+// 泛型缓存新建的计数保存为 V 类型
+func TestGenericIncrBy(t *testing.T) {
+	for _, shards := range []int{0, 4} {
+		cache := localcache.CreateGeneric[string, int]().
+			Tp(localcache.LRU).
+			Shards(shards).
+			CostFunc(func(key string, value int) int64 { return 1 }).
+			Build()
+
+		if n, err := cache.IncrBy("a", 1); n != 1 || err != nil {
+			t.Errorf("incr %d %v", n, err)
+		}
+		if n, err := cache.DecrBy("a", 3); n != -2 || err != nil {
+			t.Errorf("decr %d %v", n, err)
+		}
+		if value, err := cache.Get("a"); value != -2 || err != nil {
+			t.Errorf("get %v %v", value, err)
+		}
+	}
+
+	floats := localcache.CreateGeneric[string, float64]().Build()
+	if _, err := floats.IncrBy("a", 1); err != localcache.ErrValueType {
+		t.Errorf("float %v", err)
+	}
+}
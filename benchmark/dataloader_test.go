@@ -0,0 +1,288 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"localcache"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 记录每次调用收到的 key, 返回 key*10, 负数 key 视为不存在
+type backend struct {
+	mu    sync.Mutex
+	calls [][]interface{}
+	delay time.Duration
+}
+
+func (b *backend) load(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error) {
+	b.mu.Lock()
+	b.calls = append(b.calls, keys)
+	b.mu.Unlock()
+	time.Sleep(b.delay)
+
+	values := make(map[interface{}]interface{}, len(keys))
+	for _, key := range keys {
+		if key.(int) >= 0 {
+			values[key] = key.(int) * 10
+		}
+	}
+	return values, nil
+}
+
+func (b *backend) callCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.calls)
+}
+
+func TestBatchLoaderCoalesce(t *testing.T) {
+	for _, shards := range []int{0, 8} {
+		db := &backend{}
+		r := localcache.CreateRegister()
+		cache := localcache.Create().
+			Tp(localcache.LRU).
+			Capacity(1000).
+			Shards(shards).
+			OpenFlight(&r).
+			BatchLoader(db.load).
+			MaxBatchWait(time.Millisecond * 20).
+			Build()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				value, err := cache.GetOrLoad(context.Background(), i)
+				if err != nil || value != i*10 {
+					t.Errorf("%d: %v %v", i, value, err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		fmt.Println(shards, db.callCount())
+		if db.callCount() != 1 {
+			t.Errorf("shards %d: %d backend calls", shards, db.callCount())
+		}
+		if cache.KeyCount() != 50 || r.LoadSuccessCount() != 1 {
+			t.Errorf("shards %d: key count %d, loads %d", shards, cache.KeyCount(), r.LoadSuccessCount())
+		}
+	}
+}
+
+func TestBatchLoaderMaxSize(t *testing.T) {
+	db := &backend{}
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		Capacity(1000).
+		BatchLoader(db.load).
+		MaxBatchSize(10).
+		MaxBatchWait(time.Second).
+		Build()
+
+	keys := make([]interface{}, 35)
+	for i := range keys {
+		keys[i] = i
+	}
+
+	start := time.Now()
+	values, err := cache.GetManyOrLoad(context.Background(), keys)
+	if err != nil || len(values) != 35 {
+		t.Errorf("%d values, %v", len(values), err)
+	}
+	// 最后一批不满, 需要等到 MaxBatchWait
+	if db.callCount() != 4 || time.Since(start) < time.Second {
+		t.Errorf("%d backend calls after %v", db.callCount(), time.Since(start))
+	}
+	for _, call := range db.calls {
+		if len(call) > 10 {
+			t.Errorf("batch of %d keys", len(call))
+		}
+	}
+}
+
+func TestGetManyOrLoad(t *testing.T) {
+	for _, tp := range cacheTypes {
+		db := &backend{}
+		r := localcache.CreateRegister()
+		cache := localcache.Create().
+			Tp(tp).
+			Capacity(1000).
+			OpenFlight(&r).
+			BatchLoader(db.load).
+			Build()
+
+		cache.SetMany(map[interface{}]interface{}{1: "one", 2: "two"})
+		values, err := cache.GetManyOrLoad(context.Background(), []interface{}{1, 2, 3, 4, -1})
+		if err != nil || len(values) != 4 || values[1] != "one" || values[3] != 30 {
+			t.Errorf("%s: %v %v", tp, values, err)
+		}
+		// 只有未命中的 key 交给后端, 不存在的 key 不在结果中
+		if db.callCount() != 1 || len(db.calls[0]) != 3 {
+			t.Errorf("%s: backend calls %v", tp, db.calls)
+		}
+		if r.HitCount() != 2 || r.MissCount() != 3 {
+			t.Errorf("%s: hit %d, miss %d", tp, r.HitCount(), r.MissCount())
+		}
+
+		// 加载结果已经写入缓存
+		if value, _ := cache.Get(4); value != 40 {
+			t.Errorf("%s: get %v", tp, value)
+		}
+		if _, err := cache.GetOrLoad(context.Background(), -1); err != localcache.KeyNotFoundError {
+			t.Errorf("%s: %v", tp, err)
+		}
+	}
+}
+
+func TestGetManyOrLoadWithLoader(t *testing.T) {
+	var loads int32
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		Shards(4).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			atomic.AddInt32(&loads, 1)
+			if key == "bad" {
+				return nil, 0, errors.New("bad key")
+			}
+			return key, 0, nil
+		}).
+		Build()
+
+	cache.Set("a", "aa")
+	values, err := cache.GetManyOrLoad(context.Background(), []interface{}{"a", "b", "bad"})
+	if err == nil || len(values) != 2 || values["a"] != "aa" || values["b"] != "b" || loads != 2 {
+		t.Errorf("%v %v, %d loads", values, err, loads)
+	}
+}
+
+// Loader 返回不存在时和 BatchLoader 一样只是不在结果中, 命中负缓存前后结果一致
+func TestGetManyOrLoadNotFound(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		NegativeTTL(time.Minute).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			if key == "none" {
+				return nil, 0, localcache.ErrNotFound
+			}
+			return key, 0, nil
+		}).
+		Build()
+
+	for i := 0; i < 2; i++ {
+		values, err := cache.GetManyOrLoad(context.Background(), []interface{}{"a", "none"})
+		if err != nil || len(values) != 1 || values["a"] != "a" {
+			t.Errorf("call %d: %v %v", i, values, err)
+		}
+	}
+}
+
+// 没有 BatchLoader 时每个 key 同时加载, 总耗时接近单次加载
+func TestGetManyOrLoadParallel(t *testing.T) {
+	for _, shards := range []int{0, 4} {
+		cache := localcache.Create().
+			Tp(localcache.LRU).
+			Shards(shards).
+			Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+				time.Sleep(time.Millisecond * 100)
+				return key, 0, nil
+			}).
+			Build()
+
+		start := time.Now()
+		values, err := cache.GetManyOrLoad(context.Background(), []interface{}{1, 2, 3, 4, 5, 6, 7, 8})
+		if err != nil || len(values) != 8 || time.Since(start) > time.Millisecond*400 {
+			t.Errorf("shards %d: %v %v after %v", shards, values, err, time.Since(start))
+		}
+	}
+}
+
+// BatchLoader 多返回的 key 不写入缓存
+func TestBatchLoaderUnrequested(t *testing.T) {
+	for _, shards := range []int{0, 4} {
+		cache := localcache.Create().
+			Tp(localcache.LRU).
+			Shards(shards).
+			BatchLoader(func(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error) {
+				values := map[interface{}]interface{}{"zzz": "zzz"}
+				for _, key := range keys {
+					values[key] = key
+				}
+				return values, nil
+			}).
+			Build()
+
+		values, err := cache.GetManyOrLoad(context.Background(), []interface{}{"a", "b"})
+		if err != nil || len(values) != 2 || cache.Has("zzz") || cache.KeyCount() != 2 {
+			t.Errorf("shards %d: %v %v, %d keys", shards, values, err, cache.KeyCount())
+		}
+	}
+}
+
+// ctx 已经取消时不再加载, 也不会写入缓存
+func TestGetManyOrLoadCanceled(t *testing.T) {
+	for _, shards := range []int{0, 4} {
+		var loads int32
+		cache := localcache.Create().
+			Tp(localcache.LRU).
+			Shards(shards).
+			Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+				atomic.AddInt32(&loads, 1)
+				return key, 0, nil
+			}).
+			Build()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		values, err := cache.GetManyOrLoad(ctx, []interface{}{1, 2, 3})
+		if err != context.Canceled || len(values) != 0 || loads != 0 || cache.KeyCount() != 0 {
+			t.Errorf("shards %d: %v %v, %d loads, %d keys", shards, values, err, loads, cache.KeyCount())
+		}
+	}
+}
+
+func TestBatchLoaderContext(t *testing.T) {
+	db := &backend{delay: time.Millisecond * 200}
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		BatchLoader(db.load).
+		Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+
+	start := time.Now()
+	_, err := cache.GetManyOrLoad(ctx, []interface{}{1, 2})
+	if err != context.DeadlineExceeded || time.Since(start) > time.Millisecond*100 {
+		t.Errorf("%v after %v", err, time.Since(start))
+	}
+
+	// 批次在后台继续执行并写入缓存
+	time.Sleep(time.Millisecond * 300)
+	if !cache.Has(1) || !cache.Has(2) {
+		t.Error(cache.GetAll())
+	}
+}
+
+func TestGenericBatchLoader(t *testing.T) {
+	cache := localcache.CreateGeneric[string, int]().
+		Tp(localcache.LRU).
+		BatchLoader(func(ctx context.Context, keys []string) (map[string]int, error) {
+			values := make(map[string]int, len(keys))
+			for _, key := range keys {
+				values[key] = len(key)
+			}
+			return values, nil
+		}).
+		Build()
+
+	values, err := cache.GetManyOrLoad(context.Background(), []string{"a", "bb"})
+	if err != nil || values["bb"] != 2 {
+		t.Errorf("%v %v", values, err)
+	}
+}
@@ -21,4 +21,98 @@ func TestEvict(t *testing.T) {
 	cache.Set("c", "cc")
 
 	fmt.Println(cache.KeyCount())
-}
\ No newline at end of file
+	if cache.KeyCount() != 2 || cache.Has("a") {
+		t.Error(cache.GetAll())
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	for _, strategy := range []localcache.OverflowStrategy{localcache.OverflowFIFO, localcache.OverflowRandom} {
+		evicted := 0
+		cache := localcache.Create().
+			Tp(localcache.SIMPLE).
+			Capacity(10).
+			Overflow(strategy).
+			EvictionCallback(func(key, value interface{}, reason localcache.EvictReason) {
+				if reason == localcache.EvictCapacity {
+					evicted++
+				}
+			}).
+			Build()
+
+		for i := 0; i < 100; i++ {
+			cache.Set(i, i)
+			if i%3 == 1 {
+				cache.Remove(i)
+			}
+		}
+		if cache.KeyCount() != 10 || !cache.Has(99) {
+			t.Errorf("%d: key count %d", strategy, cache.KeyCount())
+		}
+		if evicted+cache.KeyCount()+33 != 100 {
+			t.Errorf("%d: evicted %d", strategy, evicted)
+		}
+		if strategy == localcache.OverflowFIFO && cache.Has(80) {
+			t.Error("oldest keys should be evicted first")
+		}
+	}
+}
+
+func TestOverflowReject(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.SIMPLE).
+		Capacity(2).
+		Overflow(localcache.OverflowReject).
+		Build()
+
+	cache.Set("a", "aa")
+	cache.SetWithTTL("b", "bb", time.Millisecond)
+	if err := cache.Set("c", "cc"); err != localcache.ErrCacheFull {
+		t.Errorf("err %v", err)
+	}
+	// 覆盖已有的 key 不受影响
+	if err := cache.Set("a", "aaa"); err != nil {
+		t.Error(err)
+	}
+
+	// 过期元素会被清理出空间
+	time.Sleep(time.Millisecond * 2)
+	if err := cache.Set("c", "cc"); err != nil {
+		t.Error(err)
+	}
+	if cache.KeyCount() != 2 || cache.Has("b") {
+		t.Error(cache.GetAll())
+	}
+}
+
+func TestOverflowGrow(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.SIMPLE).
+		Capacity(2).
+		Overflow(localcache.OverflowGrow).
+		Build()
+
+	for i := 0; i < 100; i++ {
+		cache.Set(i, i)
+	}
+	if cache.KeyCount() != 100 {
+		t.Errorf("key count %d", cache.KeyCount())
+	}
+}
+
+// 覆盖写入的 key 按最新写入处理, 超出总成本时先剔除其他 key
+func TestOverflowFIFOOverwrite(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.SIMPLE).
+		MaxCost(10).
+		Build()
+
+	cache.SetWithCost("a", "a1", 4)
+	cache.SetWithCost("b", "b1", 4)
+	if err := cache.SetWithCost("a", "a2", 7); err != nil {
+		t.Error(err)
+	}
+	if value, _ := cache.Get("a"); value != "a2" || cache.Has("b") {
+		t.Errorf("a = %v, %v", value, cache.GetAll())
+	}
+}
@@ -0,0 +1,57 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEvictionCallback(t *testing.T) {
+	for _, tp := range []string{localcache.SIMPLE, localcache.LRU, localcache.LFU} {
+		var mu sync.Mutex
+		reasons := make(map[localcache.EvictReason]int)
+
+		var cache localcache.Cache
+		cache = localcache.Create().
+			Tp(tp).
+			Capacity(2).
+			EvictionCallback(func(key, value interface{}, reason localcache.EvictReason) {
+				// 回调在锁外执行, 可以再次操作缓存
+				cache.Has(key)
+
+				mu.Lock()
+				reasons[reason]++
+				mu.Unlock()
+			}).
+			Build()
+
+		cache.Set("a", "aa")
+		cache.Set("a", "aaa")
+		cache.Remove("a")
+
+		cache.SetWithTTL("b", "bb", time.Millisecond)
+		time.Sleep(time.Millisecond * 2)
+		cache.Get("b")
+
+		cache.Set("c", "cc")
+		cache.Set("d", "dd")
+		cache.Set("e", "ee")
+		cache.Clear()
+
+		expected := map[localcache.EvictReason]int{
+			localcache.EvictReplaced: 1,
+			localcache.EvictRemoved:  1,
+			localcache.EvictExpired:  1,
+			localcache.EvictCapacity: 1,
+			localcache.EvictCleared:  2,
+		}
+		for reason, count := range expected {
+			if reasons[reason] != count {
+				t.Errorf("%s: %s %d, expected %d", tp, reason, reasons[reason], count)
+			}
+		}
+		fmt.Println(tp, reasons)
+	}
+}
@@ -32,4 +32,28 @@ func TestLRUExpire(t *testing.T) {
 	time.Sleep(time.Duration(time.Millisecond * 3))
 	value, _ := cache.Get("key")
 	fmt.Println(value)
-}
\ No newline at end of file
+}
+
+func TestSetWithTTL(t *testing.T) {
+	for _, tp := range []string{localcache.SIMPLE, localcache.LRU, localcache.LFU} {
+		cache := localcache.Create().
+			Tp(tp).
+			SetDuration(time.Millisecond * 2).
+			Build()
+
+		cache.SetWithTTL("short", "s", time.Millisecond)
+		cache.SetWithTTL("forever", "f", 0)
+		cache.SetWithExpireAt("long", "l", time.Now().Add(time.Hour))
+		cache.Set("default", "d")
+
+		time.Sleep(time.Millisecond * 3)
+
+		if cache.Has("short") || cache.Has("default") {
+			t.Errorf("%s: short and default should be expired", tp)
+		}
+		if !cache.Has("forever") || !cache.Has("long") {
+			t.Errorf("%s: forever and long should be kept", tp)
+		}
+		fmt.Println(tp, cache.GetAll())
+	}
+}
@@ -0,0 +1,91 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"testing"
+	"time"
+)
+
+func TestGDSF(t *testing.T) {
+	r := localcache.CreateRegister()
+	cache := localcache.Create().
+		Tp(localcache.GDSF).
+		MaxCost(100).
+		OpenFlight(&r).
+		Build()
+
+	// 大而便宜的元素每字节价值最低, 最先被剔除
+	cache.SetWithOptions("big", "big", localcache.Options{Size: 50, MissCost: 1})
+	for i := 0; i < 4; i++ {
+		cache.SetWithOptions(i, i, localcache.Options{Size: 10, MissCost: 1})
+	}
+	cache.SetWithOptions("medium", "medium", localcache.Options{Size: 20, MissCost: 1})
+	if cache.Has("big") {
+		t.Error("big should be evicted")
+	}
+
+	// 大但重新计算代价很高的元素值得保留
+	cache.SetWithOptions("expensive", "expensive", localcache.Options{Size: 50, MissCost: 100})
+	if !cache.Has("expensive") || cache.Has("medium") {
+		t.Error(cache.GetAll())
+	}
+	for i := 0; i < 4; i++ {
+		if !cache.Has(i) {
+			t.Errorf("%d should be kept", i)
+		}
+	}
+	if cache.TotalCost() != 90 || r.TotalCost() != 90 {
+		t.Errorf("total cost %d, register %d", cache.TotalCost(), r.TotalCost())
+	}
+	fmt.Println(cache.GetAll())
+}
+
+// 经常访问的元素即使比较大也会被保留
+func TestGDSFFrequency(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.GDSF).
+		MaxCost(100).
+		Build()
+
+	cache.SetWithOptions("hot", "hot", localcache.Options{Size: 40})
+	for i := 0; i < 100; i++ {
+		cache.Get("hot")
+	}
+	for i := 0; i < 100; i++ {
+		cache.SetWithOptions(i, i, localcache.Options{Size: 10, TTL: time.Minute})
+	}
+	if !cache.Has("hot") {
+		t.Error("hot should be kept")
+	}
+	if cache.KeyCount() != 7 {
+		t.Errorf("key count %d", cache.KeyCount())
+	}
+}
+
+func TestSetWithOptionsTTL(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		Build()
+
+	cache.SetWithOptions("a", "aa", localcache.Options{TTL: time.Millisecond})
+	time.Sleep(time.Millisecond * 2)
+	if value, _ := cache.Get("a"); value != nil {
+		t.Errorf("expired value %v", value)
+	}
+}
+
+// TTL 为 0 时使用 SetDuration 设置的过期时间, NoExpire 时永不过期
+func TestSetWithOptionsNoExpire(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.GDSF).
+		SetDuration(time.Millisecond).
+		Build()
+
+	cache.SetWithOptions("default", "d", localcache.Options{})
+	cache.SetWithOptions("forever", "f", localcache.Options{NoExpire: true, TTL: time.Millisecond})
+	time.Sleep(time.Millisecond * 3)
+	if cache.Has("default") || !cache.Has("forever") {
+		t.Error(cache.GetAll())
+	}
+}
@@ -0,0 +1,43 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"testing"
+)
+
+func TestGeneric(t *testing.T) {
+	cache := localcache.CreateGeneric[string, int]().
+		Tp(localcache.LRU).
+		Capacity(2).
+		Build()
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	value, err := cache.Get("a")
+	if err != nil || value != 1 {
+		t.Errorf("get a: %v, %v", value, err)
+	}
+
+	_, err = cache.Get("c")
+	if err != localcache.KeyNotFoundError {
+		t.Errorf("get c: %v", err)
+	}
+
+	fmt.Println(cache.GetAll())
+}
+
+func TestGenericCodec(t *testing.T) {
+	cache := localcache.CreateGeneric[string, string]().
+		Tp(localcache.SIMPLE).
+		SerializeFunc(localcache.DefaultSerializeFunc).
+		DeserializeFunc(localcache.DefaultDeserializeFunc).
+		Build()
+
+	cache.Set("try", "do")
+	value, err := cache.Get("try")
+	if err != nil || value != "do" {
+		t.Errorf("get try: %v, %v", value, err)
+	}
+}
@@ -0,0 +1,72 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"testing"
+	"time"
+)
+
+func TestLFU(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.LFU).
+		Capacity(2).
+		Build()
+
+	cache.Set("a", "aa")
+	cache.Set("b", "bb")
+
+	// a 被访问的次数更多, 写入 c 时应该剔除 b
+	cache.Get("a")
+	cache.Get("a")
+	cache.Set("c", "cc")
+
+	if cache.Has("b") {
+		t.Error("b should be evicted")
+	}
+	if !cache.Has("a") || !cache.Has("c") {
+		t.Error("a and c should be kept")
+	}
+	fmt.Println(cache.GetAll())
+}
+
+func TestLFUExpire(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.LFU).
+		SetDuration(time.Millisecond * 2).
+		Build()
+
+	cache.Set("key", "ok")
+	time.Sleep(time.Millisecond * 3)
+
+	value, _ := cache.Get("key")
+	if value != nil {
+		t.Errorf("expired value should be nil, got %v", value)
+	}
+	fmt.Println(cache.KeyCount())
+}
+
+// 按总成本剔除时刚写入的元素最后考虑, 访问次数少的先剔除
+func TestLFUCost(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.LFU).
+		MaxCost(10).
+		CostFunc(func(key, value interface{}) int64 {
+			return int64(len(value.(string)))
+		}).
+		Build()
+
+	cache.Set("a", "aaaa")
+	cache.Get("a")
+	cache.Set("b", "bbbb")
+	cache.Set("c", "cccc")
+	if cache.Has("b") || !cache.Has("a") || !cache.Has("c") {
+		t.Errorf("b should be evicted, got %v", cache.GetAll())
+	}
+
+	cache.Set("d", "ddddddddd")
+	if cache.KeyCount() != 1 || !cache.Has("d") {
+		t.Errorf("only d should be kept, got %v", cache.GetAll())
+	}
+	fmt.Println(cache.GetAll())
+}
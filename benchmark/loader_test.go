@@ -0,0 +1,150 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"localcache"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	r := localcache.CreateRegister()
+	var loads int32
+
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		OpenFlight(&r).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			atomic.AddInt32(&loads, 1)
+			time.Sleep(time.Millisecond * 10)
+			return "db-" + key.(string), 0, nil
+		}).
+		Build()
+
+	var sg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		sg.Add(1)
+		go func() {
+			defer sg.Done()
+			value, err := cache.GetOrLoad(context.Background(), "a")
+			if err != nil || value != "db-a" {
+				t.Errorf("get or load: %v, %v", value, err)
+			}
+		}()
+	}
+	sg.Wait()
+
+	// 并发未命中只加载一次
+	if atomic.LoadInt32(&loads) != 1 {
+		t.Errorf("loads: %d", loads)
+	}
+	if r.LoadSuccessCount() != 1 {
+		t.Errorf("load success: %d", r.LoadSuccessCount())
+	}
+
+	value, _ := cache.Get("a")
+	fmt.Println(value, r.AverageLoadTime())
+}
+
+func TestGetOrLoadError(t *testing.T) {
+	r := localcache.CreateRegister()
+	loadErr := errors.New("db down")
+
+	cache := localcache.Create().
+		Tp(localcache.SIMPLE).
+		OpenFlight(&r).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			return nil, 0, loadErr
+		}).
+		Build()
+
+	_, err := cache.GetOrLoad(context.Background(), "a")
+	if err != loadErr {
+		t.Errorf("err: %v", err)
+	}
+	if r.LoadFailureCount() != 1 || cache.Has("a") {
+		t.Errorf("load failure: %d", r.LoadFailureCount())
+	}
+}
+
+func TestRefreshAhead(t *testing.T) {
+	var version int32
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		RefreshAhead(0.5).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			return atomic.AddInt32(&version, 1), time.Millisecond * 40, nil
+		}).
+		Build()
+
+	ctx := context.Background()
+	cache.GetOrLoad(ctx, "a")
+
+	// 过了一半的存活时间, 仍然返回旧值, 同时在后台刷新
+	time.Sleep(time.Millisecond * 25)
+	value, _ := cache.GetOrLoad(ctx, "a")
+	if value != int32(1) {
+		t.Errorf("value before refresh: %v", value)
+	}
+
+	time.Sleep(time.Millisecond * 5)
+	value, _ = cache.GetOrLoad(ctx, "a")
+	if value != int32(2) {
+		t.Errorf("value after refresh: %v", value)
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	var version int32
+	cache := localcache.Create().
+		Tp(localcache.SIMPLE).
+		StaleWhileRevalidate(time.Second).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			time.Sleep(time.Millisecond * 5)
+			if atomic.AddInt32(&version, 1) == 1 {
+				return int32(1), time.Millisecond * 5, nil
+			}
+			return int32(2), time.Minute, nil
+		}).
+		Build()
+
+	ctx := context.Background()
+	cache.GetOrLoad(ctx, "a")
+	time.Sleep(time.Millisecond * 10)
+
+	// 已经过期, Get 不再返回旧值, GetOrLoad 返回旧值并在后台加载
+	if value, _ := cache.Get("a"); value != nil {
+		t.Errorf("get stale value: %v", value)
+	}
+	value, _ := cache.GetOrLoad(ctx, "a")
+	if value != int32(1) {
+		t.Errorf("stale value: %v", value)
+	}
+
+	time.Sleep(time.Millisecond * 20)
+	value, _ = cache.Get("a")
+	fmt.Println(value)
+	if value != int32(2) {
+		t.Errorf("value after revalidate: %v", value)
+	}
+}
+
+// 没有加载函数时保留窗口不生效, 过期的元素直接清理
+func TestStaleWithoutLoader(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		StaleWhileRevalidate(time.Hour).
+		CleanupInterval(time.Millisecond).
+		Build()
+	defer cache.Close()
+
+	cache.SetWithTTL("a", "aa", time.Millisecond)
+	time.Sleep(time.Millisecond * 10)
+	if cache.Has("a") || cache.KeyCount() != 0 {
+		t.Errorf("key count %d", cache.KeyCount())
+	}
+}
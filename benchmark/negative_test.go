@@ -0,0 +1,168 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"localcache"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNegativeTTL(t *testing.T) {
+	for _, tp := range cacheTypes {
+		var loads, evictions int32
+		r := localcache.CreateRegister()
+		cache := localcache.Create().
+			Tp(tp).
+			OpenFlight(&r).
+			NegativeTTL(time.Millisecond * 20).
+			EvictionCallback(func(key, value interface{}, reason localcache.EvictReason) {
+				if key == "c" {
+					atomic.AddInt32(&evictions, 1)
+				}
+			}).
+			Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+				atomic.AddInt32(&loads, 1)
+				return nil, 0, localcache.KeyNotFoundError
+			}).
+			Build()
+
+		for i := 0; i < 3; i++ {
+			if _, err := cache.GetOrLoad(context.Background(), "a"); err != localcache.KeyNotFoundError {
+				t.Errorf("%s: %v", tp, err)
+			}
+		}
+		if _, err := cache.Get("a"); err != localcache.KeyNotFoundError {
+			t.Errorf("%s: get %v", tp, err)
+		}
+		// 负缓存对外表现为不存在, 但占用容量, 计入 KeyCount
+		if cache.Has("a") || len(cache.GetAll()) != 0 || cache.KeyCount() != 1 {
+			t.Errorf("%s: %v, key count %d", tp, cache.GetAll(), cache.KeyCount())
+		}
+		if loads != 1 || r.NegativeHitCount() != 3 || r.MissCount() != 1 || r.HitCount() != 0 {
+			t.Errorf("%s: loads %d, negative hits %d, miss %d, hit %d", tp, loads, r.NegativeHitCount(), r.MissCount(), r.HitCount())
+		}
+		if ok, _ := cache.SetIfAbsent("a", "aa"); !ok {
+			t.Errorf("%s: set if absent", tp)
+		}
+
+		// 过期后重新加载, 负缓存移出时不回调
+		cache.GetOrLoad(context.Background(), "c")
+		time.Sleep(time.Millisecond * 25)
+		cache.GetOrLoad(context.Background(), "c")
+		cache.Clear()
+		if loads != 3 || evictions != 0 {
+			t.Errorf("%s: loads %d, evictions %d", tp, loads, evictions)
+		}
+	}
+}
+
+func TestErrorBackoff(t *testing.T) {
+	var loads int32
+	loadErr := errors.New("db down")
+	r := localcache.CreateRegister()
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		OpenFlight(&r).
+		ErrorBackoff(time.Millisecond * 20).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			if atomic.AddInt32(&loads, 1) == 1 {
+				return nil, 0, loadErr
+			}
+			return "aa", 0, nil
+		}).
+		Build()
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.GetOrLoad(context.Background(), "a"); err != loadErr {
+			t.Error(err)
+		}
+	}
+	if _, err := cache.Get("a"); err != localcache.KeyNotFoundError {
+		t.Error(err)
+	}
+	if loads != 1 || r.LoadFailureCount() != 1 {
+		t.Errorf("loads %d", loads)
+	}
+
+	time.Sleep(time.Millisecond * 25)
+	value, err := cache.GetOrLoad(context.Background(), "a")
+	fmt.Println(value, err)
+	if value != "aa" || loads != 2 {
+		t.Errorf("%v %v, loads %d", value, err, loads)
+	}
+}
+
+// 没有配置时保持原来的行为, 每次都调用 loader
+func TestNegativeDisabled(t *testing.T) {
+	var loads int32
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			atomic.AddInt32(&loads, 1)
+			return nil, 0, localcache.KeyNotFoundError
+		}).
+		Build()
+
+	cache.GetOrLoad(context.Background(), "a")
+	cache.GetOrLoad(context.Background(), "a")
+	if loads != 2 || cache.KeyCount() != 0 {
+		t.Errorf("loads %d, key count %d", loads, cache.KeyCount())
+	}
+}
+
+// 后台刷新失败时不会覆盖保留窗口内的旧值
+func TestErrorBackoffKeepsStale(t *testing.T) {
+	var loads int32
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		ErrorBackoff(time.Second).
+		StaleWhileRevalidate(time.Second).
+		Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+			if atomic.AddInt32(&loads, 1) == 1 {
+				return "aa", time.Millisecond * 10, nil
+			}
+			return nil, 0, errors.New("db down")
+		}).
+		Build()
+
+	cache.GetOrLoad(context.Background(), "a")
+	time.Sleep(time.Millisecond * 15)
+	for i := 0; i < 3; i++ {
+		if value, err := cache.GetOrLoad(context.Background(), "a"); value != "aa" {
+			t.Errorf("%v %v", value, err)
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+}
+
+func TestBatchNegative(t *testing.T) {
+	for _, shards := range []int{0, 4} {
+		db := &backend{}
+		r := localcache.CreateRegister()
+		cache := localcache.Create().
+			Tp(localcache.LRU).
+			Shards(shards).
+			OpenFlight(&r).
+			NegativeTTL(time.Minute).
+			BatchLoader(db.load).
+			Build()
+
+		keys := []interface{}{1, -1, -2}
+		for i := 0; i < 2; i++ {
+			values, err := cache.GetManyOrLoad(context.Background(), keys)
+			if err != nil || len(values) != 1 || values[1] != 10 {
+				t.Errorf("shards %d: %v %v", shards, values, err)
+			}
+		}
+		// 第二次全部命中, 不存在的 key 不会再交给后端
+		if db.callCount() != 1 || r.NegativeHitCount() != 2 {
+			t.Errorf("shards %d: backend calls %d, negative hits %d", shards, db.callCount(), r.NegativeHitCount())
+		}
+		if _, missing := cache.GetMany(keys); len(missing) != 2 {
+			t.Errorf("shards %d: missing %v", shards, missing)
+		}
+	}
+}
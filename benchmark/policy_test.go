@@ -0,0 +1,120 @@
+package benchmark
+
+import (
+	"container/list"
+	"fmt"
+	"localcache"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 按租户优先级剔除的 FIFO 策略, vip 租户的 key 只有在没有其他 key 时才会被剔除
+type tenantPolicy struct {
+	normal *list.List
+	vip    *list.List
+	nodes  map[interface{}]*list.Element
+}
+
+func newTenantPolicy() *tenantPolicy {
+	return &tenantPolicy{
+		normal: list.New(),
+		vip:    list.New(),
+		nodes:  make(map[interface{}]*list.Element),
+	}
+}
+
+func (p *tenantPolicy) listOf(key interface{}) *list.List {
+	if strings.HasPrefix(key.(string), "vip:") {
+		return p.vip
+	}
+	return p.normal
+}
+
+func (p *tenantPolicy) OnInsert(key interface{}) {
+	p.nodes[key] = p.listOf(key).PushFront(key)
+}
+
+func (p *tenantPolicy) OnAccess(key interface{}) {}
+
+func (p *tenantPolicy) OnRemove(key interface{}) {
+	if elem, ok := p.nodes[key]; ok {
+		p.listOf(key).Remove(elem)
+		delete(p.nodes, key)
+	}
+}
+
+func (p *tenantPolicy) Victim() (interface{}, bool) {
+	elem := p.normal.Back()
+	if elem == nil {
+		elem = p.vip.Back()
+	}
+	if elem == nil {
+		return nil, false
+	}
+	p.OnRemove(elem.Value)
+	return elem.Value, true
+}
+
+func TestCustomPolicy(t *testing.T) {
+	var evicted []interface{}
+	cache := localcache.Create().
+		Policy(func() localcache.EvictionPolicy { return newTenantPolicy() }).
+		Capacity(3).
+		SetDuration(time.Minute).
+		EvictionCallback(func(key, value interface{}, reason localcache.EvictReason) {
+			evicted = append(evicted, key)
+		}).
+		Build()
+
+	cache.Set("vip:a", 1)
+	cache.Set("vip:b", 2)
+	for i := 0; i < 5; i++ {
+		cache.Set(fmt.Sprintf("user:%d", i), i)
+	}
+
+	if !cache.Has("vip:a") || !cache.Has("vip:b") || !cache.Has("user:4") {
+		t.Error(cache.GetAll())
+	}
+	if cache.KeyCount() != 3 || len(evicted) != 4 {
+		t.Errorf("key count %d, evicted %v", cache.KeyCount(), evicted)
+	}
+
+	cache.Remove("user:4")
+	cache.Set("vip:c", 3)
+	cache.Set("vip:d", 4)
+	if cache.Has("vip:a") {
+		t.Error("oldest vip key should be evicted when no normal key is left")
+	}
+	fmt.Println(evicted)
+}
+
+// 每个分段使用自己的策略实例, 总容量不超过上限
+func TestCustomPolicyShards(t *testing.T) {
+	var created int
+	cache := localcache.Create().
+		Policy(func() localcache.EvictionPolicy {
+			created++
+			return newTenantPolicy()
+		}).
+		Capacity(100).
+		Shards(4).
+		Build()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cache.Set(fmt.Sprintf("user:%d", n*100+j), j)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if created != 4 || cache.KeyCount() > 100 {
+		t.Errorf("created %d, key count %d", created, cache.KeyCount())
+	}
+}
@@ -0,0 +1,112 @@
+package benchmark
+
+import (
+	"localcache"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 并发读写、删除、过期、清空和后台清理, 配合 go test -race 运行
+func stress(t *testing.T, name string, builder *localcache.CacheBuilder) {
+	capacity := 64
+	cache := builder.
+		Capacity(capacity).
+		SetDuration(time.Millisecond * 2).
+		CleanupInterval(time.Millisecond).
+		Build()
+	defer cache.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				key := (i*7 + g) % 200
+				switch i % 10 {
+				case 0:
+					cache.Remove(key)
+				case 1:
+					cache.SetWithTTL(key, i, time.Microsecond*time.Duration(i%500))
+				case 2:
+					cache.Has(key)
+				case 3:
+					if i%500 == 3 {
+						cache.Clear()
+					}
+					cache.KeyCount()
+				case 4:
+					cache.GetAll()
+					cache.GetMany([]interface{}{key, key + 1})
+				case 5, 6:
+					if err := cache.Set(key, i); err != nil && err != localcache.ErrCacheFull {
+						t.Errorf("%s: %v", name, err)
+					}
+				default:
+					if value, _ := cache.Get(key); value != nil {
+						if _, ok := value.(int); !ok {
+							t.Errorf("%s: value %v", name, value)
+						}
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if cache.KeyCount() > capacity {
+		t.Errorf("%s: key count %d", name, cache.KeyCount())
+	}
+}
+
+func TestSimpleStress(t *testing.T) {
+	strategies := map[string]localcache.OverflowStrategy{
+		"fifo":   localcache.OverflowFIFO,
+		"random": localcache.OverflowRandom,
+		"reject": localcache.OverflowReject,
+	}
+	for name, strategy := range strategies {
+		stress(t, name, localcache.Create().Tp(localcache.SIMPLE).Overflow(strategy))
+	}
+
+	// 不断扩容时只校验不会出现并发读写
+	cache := localcache.Create().
+		Tp(localcache.SIMPLE).
+		Capacity(1).
+		Overflow(localcache.OverflowGrow).
+		Build()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				cache.Set(g*1000+i, i)
+				cache.Get(g*1000 + i/2)
+				cache.KeyCount()
+			}
+		}(g)
+	}
+	wg.Wait()
+	if cache.KeyCount() != 8000 {
+		t.Errorf("grow: key count %d", cache.KeyCount())
+	}
+}
+
+// 所有缓存类型
+var cacheTypes = []string{
+	localcache.SIMPLE, localcache.LRU, localcache.LFU, localcache.TINYLFU, localcache.ARC, localcache.TWOQUEUE,
+	localcache.SLRU, localcache.CLOCK, localcache.CLOCKPRO, localcache.S3FIFO, localcache.GDSF,
+}
+
+func TestStress(t *testing.T) {
+	for _, tp := range cacheTypes[1:] {
+		stress(t, tp, localcache.Create().Tp(tp))
+	}
+	stress(t, "shards", localcache.Create().Tp(localcache.SIMPLE).Shards(4))
+}
+
+func BenchmarkParallelGetSimple(b *testing.B) {
+	benchmarkParallelGet(b, localcache.SIMPLE)
+}
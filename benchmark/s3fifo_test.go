@@ -0,0 +1,88 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestS3FIFO(t *testing.T) {
+	s3fifo := hotKeysAfterScan(localcache.S3FIFO, nil)
+	lru := hotKeysAfterScan(localcache.LRU, nil)
+
+	if s3fifo != 50 {
+		t.Errorf("s3fifo keeps %d hot keys", s3fifo)
+	}
+
+	// 从幽灵队列回来的 key 直接进入主队列
+	reload := hotKeysAfterReload(localcache.Create().Tp(localcache.S3FIFO))
+	if reload != 20 {
+		t.Errorf("s3fifo keeps %d reloaded keys", reload)
+	}
+	fmt.Println("s3fifo", s3fifo, "lru", lru, "reload", reload)
+}
+
+func TestS3FIFOCallback(t *testing.T) {
+	var mu sync.Mutex
+	reasons := make(map[localcache.EvictReason]int)
+	cache := localcache.Create().
+		Tp(localcache.S3FIFO).
+		Capacity(10).
+		EvictionCallback(func(key, value interface{}, reason localcache.EvictReason) {
+			mu.Lock()
+			reasons[reason]++
+			mu.Unlock()
+		}).
+		Build()
+
+	cache.SetWithTTL("a", "aa", time.Millisecond)
+	time.Sleep(time.Millisecond * 2)
+	if value, _ := cache.Get("a"); value != nil {
+		t.Errorf("expired value %v", value)
+	}
+
+	for i := 0; i < 100; i++ {
+		cache.Set(i, i)
+	}
+	if cache.KeyCount() != 10 {
+		t.Errorf("key count %d", cache.KeyCount())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if reasons[localcache.EvictExpired] != 1 || reasons[localcache.EvictCapacity] != 90 {
+		t.Error(reasons)
+	}
+}
+
+func TestS3FIFOConcurrent(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.S3FIFO).
+		Capacity(100).
+		Build()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				key := (i * (g + 1)) % 300
+				if v, _ := cache.Get(key); v == nil {
+					cache.Set(key, key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if cache.KeyCount() > 100 {
+		t.Errorf("key count %d", cache.KeyCount())
+	}
+}
+
+func BenchmarkParallelGetS3FIFO(b *testing.B) {
+	benchmarkParallelGet(b, localcache.S3FIFO)
+}
@@ -0,0 +1,166 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestShards(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		Capacity(1024).
+		Shards(16).
+		Build()
+
+	var sg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		sg.Add(1)
+		go func(n int) {
+			defer sg.Done()
+			for j := 0; j < 100; j++ {
+				key := strconv.Itoa(n*100 + j)
+				cache.Set(key, j)
+				cache.Get(key)
+			}
+		}(i)
+	}
+	sg.Wait()
+
+	if cache.KeyCount() != 800 {
+		t.Errorf("key count: %d", cache.KeyCount())
+	}
+	fmt.Println(cache.KeyCount())
+}
+
+type userID int64
+
+type privKey struct {
+	id int
+}
+
+// 自定义类型和带未导出字段的结构体也要均匀分到各个分段
+func TestShardsKeyTypes(t *testing.T) {
+	keys := map[string]func(i int) interface{}{
+		"named int": func(i int) interface{} { return userID(i) },
+		"struct":    func(i int) interface{} { return privKey{i} },
+	}
+	for name, key := range keys {
+		cache := localcache.Create().
+			Tp(localcache.LRU).
+			Capacity(1600).
+			Shards(16).
+			Build()
+
+		for i := 0; i < 1000; i++ {
+			cache.Set(key(i), i)
+		}
+		if cache.KeyCount() != 1000 {
+			t.Errorf("%s: key count %d", name, cache.KeyCount())
+		}
+		if value, err := cache.Get(key(7)); value != 7 || err != nil {
+			t.Errorf("%s: get %v %v", name, value, err)
+		}
+	}
+}
+
+type floatKey struct {
+	f float64
+}
+
+// 按 == 的语义选择分段: 指针按地址, 修改指向的值不影响查找; -0 和 0 是同一个 key
+func TestShardsKeyEquality(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		Capacity(1600).
+		Shards(16).
+		Build()
+
+	keys := make([]*privKey, 100)
+	for i := range keys {
+		keys[i] = &privKey{i}
+		cache.Set(keys[i], i)
+	}
+	for i, key := range keys {
+		key.id = -i - 1
+		if value, err := cache.Get(key); value != i || err != nil {
+			t.Errorf("pointer %d: %v %v", i, value, err)
+		}
+	}
+
+	negZero := math.Copysign(0, -1)
+	cache.Set(floatKey{negZero}, "a")
+	cache.Set(floatKey{0}, "b")
+	if value, _ := cache.Get(floatKey{negZero}); value != "b" || cache.KeyCount() != 101 {
+		t.Errorf("float: %v, key count %d", value, cache.KeyCount())
+	}
+}
+
+// 分段后总容量和总成本不超过配置的上限
+func TestShardsBound(t *testing.T) {
+	for _, c := range []struct{ capacity, shards int }{{100, 64}, {3, 16}, {1000, 16}} {
+		cache := localcache.Create().
+			Tp(localcache.LRU).
+			Capacity(c.capacity).
+			Shards(c.shards).
+			Build()
+
+		for i := 0; i < c.capacity*4; i++ {
+			cache.Set(i, i)
+		}
+		if cache.KeyCount() > c.capacity {
+			t.Errorf("capacity %d shards %d: key count %d", c.capacity, c.shards, cache.KeyCount())
+		}
+	}
+
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		MaxCost(10).
+		Shards(16).
+		Build()
+	for i := 0; i < 100; i++ {
+		cache.SetWithCost(i, i, 1)
+	}
+	if cache.TotalCost() > 10 {
+		t.Errorf("total cost %d", cache.TotalCost())
+	}
+}
+
+func benchmarkMixed(b *testing.B, shards int) {
+	cache := localcache.Create().
+		Tp(localcache.LRU).
+		Capacity(1 << 16).
+		Shards(shards).
+		Build()
+
+	keys := make([]string, 1<<12)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+		cache.Set(keys[i], i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[i&(len(keys)-1)]
+			if i%4 == 0 {
+				cache.Set(key, i)
+			} else {
+				cache.Get(key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkMixedSingle(b *testing.B) {
+	benchmarkMixed(b, 1)
+}
+
+func BenchmarkMixedShards(b *testing.B) {
+	benchmarkMixed(b, 64)
+}
@@ -0,0 +1,47 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"testing"
+)
+
+func TestSLRU(t *testing.T) {
+	slru := hotKeysAfterScan(localcache.SLRU, nil)
+	lru := hotKeysAfterScan(localcache.LRU, nil)
+
+	if slru != 50 {
+		t.Errorf("slru keeps %d hot keys", slru)
+	}
+	fmt.Println("slru", slru, "lru", lru)
+}
+
+func TestSLRUProtectedRatio(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.SLRU).
+		Capacity(100).
+		SLRUProtectedRatio(0.1).
+		Build()
+
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 50; i++ {
+			if v, _ := cache.Get(i); v == nil {
+				cache.Set(i, i)
+			}
+		}
+	}
+	for i := 1000; i < 2000; i++ {
+		cache.Set(i, i)
+	}
+
+	// 保护段只能放下 10 个 key, 其余的热点 key 被降级后和扫描一起剔除
+	hot := 0
+	for i := 0; i < 50; i++ {
+		if cache.Has(i) {
+			hot++
+		}
+	}
+	if hot != 10 {
+		t.Errorf("slru keeps %d hot keys", hot)
+	}
+}
@@ -0,0 +1,66 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"testing"
+)
+
+// 热点 key 被多次访问后, 一次大批量扫描不应该把它们冲掉
+func hotKeysAfterScan(tp string, r *localcache.RegisterAccessor) int {
+	builder := localcache.Create().
+		Tp(tp).
+		Capacity(100)
+	if r != nil {
+		builder.OpenFlight(r)
+	}
+	cache := builder.Build()
+
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 50; i++ {
+			if v, _ := cache.Get(i); v == nil {
+				cache.Set(i, i)
+			}
+		}
+	}
+	for i := 1000; i < 11000; i++ {
+		cache.Set(i, i)
+	}
+
+	hot := 0
+	for i := 0; i < 50; i++ {
+		if cache.Has(i) {
+			hot++
+		}
+	}
+	return hot
+}
+
+func TestTinyLFU(t *testing.T) {
+	r := localcache.CreateRegister()
+	tinyLFU := hotKeysAfterScan(localcache.TINYLFU, &r)
+	lru := hotKeysAfterScan(localcache.LRU, nil)
+
+	if tinyLFU < 45 {
+		t.Errorf("tinylfu keeps %d hot keys", tinyLFU)
+	}
+	if r.RejectCount() == 0 {
+		t.Error("scan keys should be rejected")
+	}
+	fmt.Println("tinylfu", tinyLFU, "lru", lru, "admit", r.AdmitCount(), "reject", r.RejectCount())
+}
+
+func TestTinyLFUCapacity(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.TINYLFU).
+		Capacity(10).
+		Build()
+
+	for i := 0; i < 100; i++ {
+		cache.Set(i, i)
+		cache.Get(i)
+	}
+	if cache.KeyCount() != 10 {
+		t.Errorf("key count %d", cache.KeyCount())
+	}
+}
@@ -0,0 +1,72 @@
+package benchmark
+
+import (
+	"fmt"
+	"localcache"
+	"testing"
+	"time"
+)
+
+// 热点 key 被扫描冲掉后立即重新加载, 2Q 应该通过 A1out 认出它们并放进 Am
+func hotKeysAfterReload(builder *localcache.CacheBuilder) int {
+	cache := builder.Capacity(100).Build()
+
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 20; i++ {
+			if v, _ := cache.Get(i); v == nil {
+				cache.Set(i, i)
+			}
+		}
+		for i := 0; i < 100; i++ {
+			cache.Set(1000+round*100+i, i)
+		}
+	}
+
+	hot := 0
+	for i := 0; i < 20; i++ {
+		if cache.Has(i) {
+			hot++
+		}
+	}
+	return hot
+}
+
+func TestTwoQueue(t *testing.T) {
+	twoQueue := hotKeysAfterReload(localcache.Create().Tp(localcache.TWOQUEUE))
+	lru := hotKeysAfterReload(localcache.Create().Tp(localcache.LRU))
+
+	if twoQueue != 20 {
+		t.Errorf("2q keeps %d hot keys", twoQueue)
+	}
+	fmt.Println("2q", twoQueue, "lru", lru)
+}
+
+func TestTwoQueueRatio(t *testing.T) {
+	// A1out 太小时热点 key 的幽灵记录会被扫描冲掉
+	hot := hotKeysAfterReload(localcache.Create().Tp(localcache.TWOQUEUE).TwoQueueRatio(0.25, 0.05))
+	if hot == 20 {
+		t.Error("ghost queue should be too small to remember hot keys")
+	}
+	fmt.Println("2q small ghost", hot)
+}
+
+func TestTwoQueueExpire(t *testing.T) {
+	cache := localcache.Create().
+		Tp(localcache.TWOQUEUE).
+		Capacity(10).
+		SetDuration(time.Millisecond).
+		Build()
+
+	cache.Set("a", "aa")
+	time.Sleep(time.Millisecond * 2)
+	if value, _ := cache.Get("a"); value != nil {
+		t.Errorf("expired value %v", value)
+	}
+
+	for i := 0; i < 100; i++ {
+		cache.Set(i, i)
+	}
+	if cache.KeyCount() != 10 {
+		t.Errorf("key count %d", cache.KeyCount())
+	}
+}
@@ -1,18 +1,40 @@
 package localcache
 
 import (
-	"errors"
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Cache interface {
-	Set(key, value interface{}) error         // 写入
-	Get(key interface{}) (interface{}, error) // 抽取
-	Remove(key interface{}) error             // 删除
-	GetAll() map[interface{}]interface{}      // 获取所有
-	KeyCount() int                            // key 的数量
-	Has(key interface{}) bool                 // 校验 key 是否存在
+	Set(key, value interface{}) error                                                           // 写入
+	SetWithTTL(key, value interface{}, ttl time.Duration) error                                 // 写入并指定存活时间, 0 表示永不过期
+	SetWithExpireAt(key, value interface{}, expireAt time.Time) error                           // 写入并指定过期时刻, 零值表示永不过期
+	SetWithCost(key, value interface{}, cost int64) error                                       // 写入并指定成本
+	SetWithOptions(key, value interface{}, opts Options) error                                  // 写入并指定大小和未命中代价
+	Get(key interface{}) (interface{}, error)                                                   // 抽取
+	Remove(key interface{}) error                                                               // 删除
+	GetAll() map[interface{}]interface{}                                                        // 获取所有
+	KeyCount() int                                                                              // key 的数量, 包括占用容量的负缓存
+	Has(key interface{}) bool                                                                   // 校验 key 是否存在
+	TotalCost() int64                                                                           // 当前总成本
+	GetCtx(ctx context.Context, key interface{}) (interface{}, error)                           // 抽取, ctx 结束时返回 ctx.Err()
+	SetCtx(ctx context.Context, key, value interface{}) error                                   // 写入, ctx 结束时不再写入
+	GetOrLoad(ctx context.Context, key interface{}) (interface{}, error)                        // 抽取, 未命中时通过 loader 加载
+	GetManyOrLoad(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error) // 批量读取, 未命中的 key 通过加载函数加载
+	SetIfAbsent(key, value interface{}) (bool, error)                                           // 不存在时写入
+	Replace(key, value interface{}) (bool, error)                                               // 存在时覆盖
+	CompareAndSwap(key, old, new interface{}) (bool, error)                                     // 当前值等于 old 时写入 new
+	GetAndDelete(key interface{}) (interface{}, error)                                          // 读取并删除
+	Compute(key interface{}, fn ComputeFunc) (interface{}, error)                               // 根据当前值计算新值
+	GetMany(keys []interface{}) (map[interface{}]interface{}, []interface{})                    // 批量读取, 返回命中的值和未命中的 key
+	SetMany(items map[interface{}]interface{}) error                                            // 批量写入
+	RemoveMany(keys []interface{}) int                                                          // 批量删除, 返回删除的数量
+	IncrBy(key interface{}, delta int64) (int64, error)                                         // 原子地加上 delta
+	DecrBy(key interface{}, delta int64) (int64, error)                                         // 原子地减去 delta
+	Clear()                                                                                     // 清空
+	Close() error                                                                               // 关闭, 停止后台清理
 }
 
 type (
@@ -20,37 +42,123 @@ type (
 	SerializeFunc   func(interface{}) (interface{}, error) // 序列化
 	DeserializeFunc func(interface{}) (interface{}, error) // 反序列化
 	ExpireFunc      func()                                 // 超时函数
+	CostFunc        func(key, value interface{}) int64     // 计算成本
+
+	// 元素移出缓存后的回调, 配置了序列化时 value 为序列化后的值
+	EvictionCallback func(key, value interface{}, reason EvictReason)
+
+	// 带 ctx 的序列化、反序列化和回调, ctx 来自 GetCtx、SetCtx、GetOrLoad 等调用方
+	// 没有调用方的场景, 比如后台清理和不带 ctx 的方法, ctx 为 context.Background()
+	SerializeCtxFunc    func(ctx context.Context, value interface{}) (interface{}, error)
+	DeserializeCtxFunc  func(ctx context.Context, value interface{}) (interface{}, error)
+	AddCtxCallback      func(ctx context.Context, key, value interface{})
+	EvictionCtxCallback func(ctx context.Context, key, value interface{}, reason EvictReason)
+
+	// 加载函数, 返回的 ttl 为 0 时使用 SetDuration 设置的过期时间
+	// 一次加载被同一个 key 的所有调用方共享, ctx 保留调用方的值, 所有调用方都放弃等待后被取消
+	LoaderFunc func(ctx context.Context, key interface{}) (value interface{}, ttl time.Duration, err error)
+
+	// 批量加载函数, 返回 keys 中能找到的值, 结果使用 SetDuration 设置的过期时间
+	// 一个批次合并了多个调用方, ctx 保留开启批次的调用方的值但不会被取消
+	BatchLoaderFunc func(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error)
+
+	// 创建自定义淘汰策略, 策略实例只能服务一个缓存, 每次调用需要返回新的实例
+	PolicyFactory func() EvictionPolicy
+
+	// 根据当前值计算新值, 不存在时 old 为 nil, keep 为 false 时删除
+	ComputeFunc func(old interface{}, exists bool) (value interface{}, keep bool)
 )
 
+// 写入选项
+type Options struct {
+	MissCost int64         // 未命中时重新获取的代价, 小于等于 0 时为 1
+	Size     int64         // 占用的大小, 即计入 MaxCost 的成本, 小于等于 0 时和 Set 一样计算
+	TTL      time.Duration // 存活时间, 0 表示使用 SetDuration 设置的过期时间, 和 SetWithTTL 不同
+	NoExpire bool          // 永不过期, 忽略 TTL 和 SetDuration
+}
+
+// 具体缓存需要实现的存储操作, basicCache 中的通用逻辑基于它实现
+// 返回的 evictedItem 由 basicCache 在锁外统一回调
+type store interface {
+	setValue(key, value interface{}, meta itemMeta) ([]evictedItem, error)  // 写入, 返回被覆盖和被剔除的元素
+	getValue(key interface{}, now time.Time) (interface{}, itemMeta, error) // 读取, 超出保留窗口的元素会被删除后返回
+	deleteExpired(now time.Time) []evictedItem                              // 删除所有超出保留窗口的元素
+	clear() []evictedItem                                                   // 清空所有元素
+	totalCost() int64                                                       // 当前总成本
+
+	// 以下方法由调用方持有锁, 用于在一次加锁内组合多个操作
+	put(key, value interface{}, meta itemMeta) ([]evictedItem, error)  // 同 setValue
+	get(key interface{}, now time.Time) (interface{}, itemMeta, error) // 同 getValue
+	peek(key interface{}) (interface{}, itemMeta, bool)                // 读取, 不算作访问, 也不检查是否失效
+	remove(key interface{}) (evictedItem, bool)                        // 删除, 返回 EvictRemoved
+}
+
+// 命中时只需要读锁的存储, 调用方持有读锁
+type sharedStore interface {
+	// 读取并记录访问, 不存在时返回 nil, 元素已经失效时返回 true, 需要换成写锁删除
+	getShared(key interface{}, now time.Time) (interface{}, itemMeta, bool)
+}
+
 type basicCache struct {
+	store    store             // 具体的存储实现
+	shared   sharedStore       // 存储支持读锁命中时不为 nil
 	capacity int               // 容量
+	maxCost  int64             // 总成本上限
 	duration *time.Duration    // 过期时间
 	register *RegisterAccessor // 计数器
 	flight   bool              // 是否启动飞行器
 	mu       sync.RWMutex
+	janitor  *janitor // 后台清理过期元素
+	closed   int32    // 已经调用过 Close
 
-	serializeFunc   SerializeFunc
-	deserializeFunc DeserializeFunc
-	expireFunc      ExpireFunc
-	addCallback     ADDCallback
+	cleanupInterval  time.Duration
+	serializeFunc    SerializeCtxFunc
+	deserializeFunc  DeserializeCtxFunc
+	expireFunc       ExpireFunc
+	evictionCallback EvictionCtxCallback
+	addCallback      AddCtxCallback
+	costFunc         CostFunc
+	loader           LoaderFunc
+	loads            loadGroup     // 合并同一个 key 的并发加载
+	batcher          *batcher      // 合并并发的未命中批量加载
+	refreshAhead     float64       // 存活时间过去该比例后异步刷新
+	staleWindow      time.Duration // 过期后继续返回旧值的窗口
+	negativeTTL      time.Duration // 记住 key 不存在的时间
+	errorBackoff     time.Duration // 记住加载失败的时间
 }
 
 // 组织器
 type CacheBuilder struct {
-	capacity        int
-	mu              sync.RWMutex
-	serializeFunc   SerializeFunc
-	deserializeFunc DeserializeFunc
-	expireFunc      ExpireFunc
-	tp              string
-	duration        *time.Duration
-	flight          bool
-	register        *RegisterAccessor // 计数器
-	addCallback     ADDCallback
+	capacity         int
+	maxCost          int64
+	costFunc         CostFunc
+	shards           int // 分段数
+	serializeFunc    SerializeCtxFunc
+	deserializeFunc  DeserializeCtxFunc
+	expireFunc       ExpireFunc
+	tp               string
+	duration         *time.Duration
+	flight           bool
+	register         *RegisterAccessor // 计数器
+	addCallback      AddCtxCallback
+	evictionCallback EvictionCtxCallback
+	cleanupInterval  time.Duration // 后台清理间隔
+	loader           LoaderFunc
+	refreshAhead     float64
+	staleWindow      time.Duration
+	recentRatio      float64          // 2Q 的 A1in 比例
+	ghostRatio       float64          // 2Q 的 A1out 比例
+	protectedRatio   float64          // SLRU 的保护段比例
+	policy           PolicyFactory    // 自定义淘汰策略
+	overflow         OverflowStrategy // SIMPLE 超出容量时的处理方式
+	batchLoader      BatchLoaderFunc
+	maxBatchSize     int
+	maxBatchWait     time.Duration
+	batcher          *batcher // 分段共享的批量加载器
+	negativeTTL      time.Duration
+	errorBackoff     time.Duration
 }
 
-var KeyNotFoundError = errors.New("key not found .")
-
 // 创建一个构造器
 func Create() *CacheBuilder {
 	return &CacheBuilder{
@@ -61,12 +169,34 @@ func Create() *CacheBuilder {
 
 // 组织序列化
 func (builder *CacheBuilder) SerializeFunc(fc SerializeFunc) *CacheBuilder {
-	builder.serializeFunc = fc
+	builder.serializeFunc = nil
+	if fc != nil {
+		builder.serializeFunc = func(ctx context.Context, value interface{}) (interface{}, error) {
+			return fc(value)
+		}
+	}
 	return builder
 }
 
 // 组织反序列化
 func (builder *CacheBuilder) DeserializeFunc(fc DeserializeFunc) *CacheBuilder {
+	builder.deserializeFunc = nil
+	if fc != nil {
+		builder.deserializeFunc = func(ctx context.Context, value interface{}) (interface{}, error) {
+			return fc(value)
+		}
+	}
+	return builder
+}
+
+// 带 ctx 的序列化, 可以响应调用方的取消并读取请求范围的值, 和 SerializeFunc 互相覆盖
+func (builder *CacheBuilder) SerializeCtxFunc(fc SerializeCtxFunc) *CacheBuilder {
+	builder.serializeFunc = fc
+	return builder
+}
+
+// 带 ctx 的反序列化, 和 DeserializeFunc 互相覆盖
+func (builder *CacheBuilder) DeserializeCtxFunc(fc DeserializeCtxFunc) *CacheBuilder {
 	builder.deserializeFunc = fc
 	return builder
 }
@@ -76,7 +206,25 @@ func (builder *CacheBuilder) Tp(tp string) *CacheBuilder {
 	return builder
 }
 
+// 使用自定义的淘汰策略, 设置后忽略 Tp
+// 设置 Shards 时每个分段调用一次 factory, 使用各自的策略实例
+func (builder *CacheBuilder) Policy(factory PolicyFactory) *CacheBuilder {
+	builder.policy = factory
+	return builder
+}
+
 func (builder *CacheBuilder) AddCallback(fc ADDCallback) *CacheBuilder {
+	builder.addCallback = nil
+	if fc != nil {
+		builder.addCallback = func(ctx context.Context, key, value interface{}) {
+			fc(key, value)
+		}
+	}
+	return builder
+}
+
+// 带 ctx 的写入回调, 和 AddCallback 互相覆盖
+func (builder *CacheBuilder) AddCtxCallback(fc AddCtxCallback) *CacheBuilder {
 	builder.addCallback = fc
 	return builder
 }
@@ -86,17 +234,124 @@ func (builder *CacheBuilder) ExpireFunc(fc ExpireFunc) *CacheBuilder {
 	return builder
 }
 
+// 元素因过期、容量、删除、覆盖或清空移出缓存后回调, 回调在锁外执行
+func (builder *CacheBuilder) EvictionCallback(fc EvictionCallback) *CacheBuilder {
+	builder.evictionCallback = nil
+	if fc != nil {
+		builder.evictionCallback = func(ctx context.Context, key, value interface{}, reason EvictReason) {
+			fc(key, value, reason)
+		}
+	}
+	return builder
+}
+
+// 带 ctx 的移出回调, ctx 来自触发移出的调用, 和 EvictionCallback 互相覆盖
+func (builder *CacheBuilder) EvictionCtxCallback(fc EvictionCtxCallback) *CacheBuilder {
+	builder.evictionCallback = fc
+	return builder
+}
+
 func (builder *CacheBuilder) SetDuration(duration time.Duration) *CacheBuilder {
 	builder.duration = &duration
 	return builder
 }
 
+// 开启后台清理, 每隔 interval 主动删除过期元素, 需要调用 Close 停止
+func (builder *CacheBuilder) CleanupInterval(interval time.Duration) *CacheBuilder {
+	builder.cleanupInterval = interval
+	return builder
+}
+
+// 设置加载函数, GetOrLoad 未命中时调用
+func (builder *CacheBuilder) Loader(fc LoaderFunc) *CacheBuilder {
+	builder.loader = fc
+	return builder
+}
+
+// 设置批量加载函数, 并发的未命中合并成批次加载
+// 只设置 BatchLoader 时 GetOrLoad 也通过批次加载
+func (builder *CacheBuilder) BatchLoader(fc BatchLoaderFunc) *CacheBuilder {
+	builder.batchLoader = fc
+	return builder
+}
+
+// 每批最多的 key 数量, 达到后立即加载, 小于等于 0 表示不限制
+func (builder *CacheBuilder) MaxBatchSize(n int) *CacheBuilder {
+	builder.maxBatchSize = n
+	return builder
+}
+
+// 批次在第一个 key 进入后最多等待的时间, 默认为 1ms
+func (builder *CacheBuilder) MaxBatchWait(wait time.Duration) *CacheBuilder {
+	builder.maxBatchWait = wait
+	return builder
+}
+
+// 加载函数返回 KeyNotFoundError, 或者批量加载没有返回某个 key 时, 在 ttl 内记住该 key 不存在
+// 期间 Get 和 GetOrLoad 直接返回 KeyNotFoundError, 不再调用加载函数
+// 负缓存同样占用容量并计入 KeyCount, 但 Has 和 GetAll 看不到它
+func (builder *CacheBuilder) NegativeTTL(ttl time.Duration) *CacheBuilder {
+	builder.negativeTTL = ttl
+	return builder
+}
+
+// 加载失败时在 backoff 内记住这个错误, 期间 GetOrLoad 直接返回该错误, 默认不记录
+func (builder *CacheBuilder) ErrorBackoff(backoff time.Duration) *CacheBuilder {
+	builder.errorBackoff = backoff
+	return builder
+}
+
+// 存活时间过去 fraction 比例后, GetOrLoad 命中时在后台重新加载, 取值范围 (0, 1)
+func (builder *CacheBuilder) RefreshAhead(fraction float64) *CacheBuilder {
+	builder.refreshAhead = fraction
+	return builder
+}
+
+// 过期后的 window 时间内, GetOrLoad 继续返回旧值并在后台重新加载
+func (builder *CacheBuilder) StaleWhileRevalidate(window time.Duration) *CacheBuilder {
+	builder.staleWindow = window
+	return builder
+}
+
 // 设置容量
 func (builder *CacheBuilder) Capacity(capacity int) *CacheBuilder {
 	builder.capacity = capacity
 	return builder
 }
 
+// 设置 SIMPLE 超出容量或总成本时的处理方式, 默认剔除最早写入的元素
+// 只有设置为 OverflowGrow 时才会不断扩容, 此时 Capacity 和 MaxCost 都不再限制写入
+func (builder *CacheBuilder) Overflow(strategy OverflowStrategy) *CacheBuilder {
+	builder.overflow = strategy
+	return builder
+}
+
+// 设置总成本上限, 超出后按淘汰策略剔除直到总成本不超过上限
+// 单个元素的成本超过上限时写入返回 ErrCacheFull
+func (builder *CacheBuilder) MaxCost(maxCost int64) *CacheBuilder {
+	builder.maxCost = maxCost
+	return builder
+}
+
+// 设置成本计算函数, 不设置时每个元素的成本为 1
+func (builder *CacheBuilder) CostFunc(fc CostFunc) *CacheBuilder {
+	builder.costFunc = fc
+	return builder
+}
+
+// 设置 2Q 的 A1in 和 A1out 占容量的比例, 默认为 0.25 和 0.5
+func (builder *CacheBuilder) TwoQueueRatio(recent, ghost float64) *CacheBuilder {
+	builder.recentRatio = recent
+	builder.ghostRatio = ghost
+	return builder
+}
+
+// 设置 SLRU 保护段占容量的比例, 默认为 0.8
+func (builder *CacheBuilder) SLRUProtectedRatio(ratio float64) *CacheBuilder {
+	builder.protectedRatio = ratio
+	return builder
+}
+
 // 启动飞行器
 func (builder *CacheBuilder) OpenFlight(r *RegisterAccessor) *CacheBuilder {
 	builder.flight = true
@@ -104,23 +359,340 @@ func (builder *CacheBuilder) OpenFlight(r *RegisterAccessor) *CacheBuilder {
 	return builder
 }
 
+// 分段存储, 每个分段独立加锁, 容量平均分配到各分段, 分段数不会超过容量
+func (builder *CacheBuilder) Shards(n int) *CacheBuilder {
+	builder.shards = n
+	return builder
+}
+
 func (builder *CacheBuilder) Build() Cache {
-	if builder.tp == SIMPLE {
+	if builder.shards > 1 {
+		m := newConcurrentMap(builder)
+		if m.segments[0] == nil {
+			return nil
+		}
+		return m
+	}
+	return builder.build()
+}
+
+func (builder *CacheBuilder) build() Cache {
+	if builder.policy != nil {
+		return newPolicyCache(builder, builder.policy())
+	} else if builder.tp == SIMPLE {
 		return newSimpleCache(builder)
 	} else if builder.tp == LRU {
-		return newLRUCache(builder)
+		return newPolicyCache(builder, newLRU())
+	} else if builder.tp == LFU {
+		return newPolicyCache(builder, newLFU())
+	} else if builder.tp == TINYLFU {
+		return newPolicyCache(builder, newTinyLFU(builder))
+	} else if builder.tp == ARC {
+		return newPolicyCache(builder, newARC(builder))
+	} else if builder.tp == TWOQUEUE {
+		return newPolicyCache(builder, newTwoQueue(builder))
+	} else if builder.tp == SLRU {
+		return newPolicyCache(builder, newSLRU(builder))
+	} else if builder.tp == CLOCK {
+		return newPolicyCache(builder, newClock())
+	} else if builder.tp == CLOCKPRO {
+		return newPolicyCache(builder, newClockPro(builder))
+	} else if builder.tp == S3FIFO {
+		return newPolicyCache(builder, newS3FIFO(builder))
+	} else if builder.tp == GDSF {
+		return newPolicyCache(builder, newGDSF())
 	}
 	return nil
 }
 
-func buildCache(c *basicCache, cb *CacheBuilder) {
+func buildCache(c *basicCache, cb *CacheBuilder, s store) {
+	c.store = s
 	c.deserializeFunc = cb.deserializeFunc
 	c.serializeFunc = cb.serializeFunc
 	c.expireFunc = cb.expireFunc
-	c.serializeFunc = cb.serializeFunc
 	c.capacity = cb.capacity
+	c.maxCost = cb.maxCost
+	c.costFunc = cb.costFunc
 	c.duration = cb.duration
 	c.flight = cb.flight
 	c.register = cb.register
 	c.addCallback = cb.addCallback
+	c.evictionCallback = cb.evictionCallback
+	c.cleanupInterval = cb.cleanupInterval
+	c.loader = cb.loader
+	c.refreshAhead = cb.refreshAhead
+	c.staleWindow = cb.staleWindow
+	c.negativeTTL = cb.negativeTTL
+	c.errorBackoff = cb.errorBackoff
+	if cb.batcher != nil {
+		c.batcher = cb.batcher
+	} else if cb.batchLoader != nil {
+		c.batcher = newBatcher(cb, c.SetMany, c.cacheFailure)
+	}
+}
+
+func (c *basicCache) Set(key, value interface{}) error {
+	return c.set(context.Background(), key, value, c.defaultExpiration(), 0, 0)
+}
+
+// ctx 传给序列化和回调, ctx 已经结束时返回 ctx.Err() 并且不写入
+func (c *basicCache) SetCtx(ctx context.Context, key, value interface{}) error {
+	return c.set(ctx, key, value, c.defaultExpiration(), 0, 0)
+}
+
+// 成本小于等于 0 时和 Set 一样计算成本
+func (c *basicCache) SetWithCost(key, value interface{}, cost int64) error {
+	return c.set(context.Background(), key, value, c.defaultExpiration(), cost, 0)
+}
+
+// 大小决定占用多少 MaxCost, 代价和大小一起供 GDSF 等策略计算每字节的价值
+func (c *basicCache) SetWithOptions(key, value interface{}, opts Options) error {
+	if opts.NoExpire {
+		return c.set(context.Background(), key, value, nil, opts.Size, opts.MissCost)
+	}
+	if opts.TTL == 0 {
+		return c.set(context.Background(), key, value, c.defaultExpiration(), opts.Size, opts.MissCost)
+	}
+	t := time.Now().Add(opts.TTL)
+	return c.set(context.Background(), key, value, &t, opts.Size, opts.MissCost)
+}
+
+// SetDuration 设置的过期时刻
+func (c *basicCache) defaultExpiration() *time.Time {
+	if c.duration == nil {
+		return nil
+	}
+	t := time.Now().Add(*c.duration)
+	return &t
+}
+
+func (c *basicCache) SetWithTTL(key, value interface{}, ttl time.Duration) error {
+	return c.set(context.Background(), key, value, c.expirationAfter(ttl), 0, 0)
+}
+
+// 存活时间对应的过期时刻, 0 表示永不过期
+func (c *basicCache) expirationAfter(ttl time.Duration) *time.Time {
+	if ttl == 0 {
+		return nil
+	}
+	t := time.Now().Add(ttl)
+	return &t
+}
+
+func (c *basicCache) SetWithExpireAt(key, value interface{}, expireAt time.Time) error {
+	var expiration *time.Time
+	if !expireAt.IsZero() {
+		expiration = &expireAt
+	}
+	return c.set(context.Background(), key, value, expiration, 0, 0)
+}
+
+// cost 小于等于 0 时通过 CostFunc 计算, 没有 CostFunc 时为 1, missCost 小于等于 0 时为 1
+// 序列化之前和之后都检查 ctx, 已经结束时返回 ctx.Err()
+func (c *basicCache) set(ctx context.Context, key, value interface{}, expiration *time.Time, cost, missCost int64) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if cost <= 0 {
+		cost = c.costOf(key, value)
+	}
+	if missCost <= 0 {
+		missCost = 1
+	}
+	if c.oversized(cost) {
+		return ErrCacheFull
+	}
+
+	value, err := c.serialize(ctx, value)
+	if err != nil {
+		return codecErr(err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	meta := c.newMeta(expiration)
+	meta.cost = cost
+	meta.missCost = missCost
+	evicted, err := c.store.setValue(key, value, meta)
+	c.notifyCtx(ctx, evicted...)
+	if err != nil {
+		return err
+	}
+	if c.flight {
+		(*c.register).AddCost(cost)
+	}
+
+	if c.addCallback != nil {
+		c.addCallback(ctx, key, value)
+	}
+	return nil
+}
+
+// 单个元素的成本超过 MaxCost 时无论剔除多少元素都放不下, 直接拒绝写入
+func (c *basicCache) oversized(cost int64) bool {
+	return c.maxCost > 0 && cost > c.maxCost
+}
+
+// 通过 CostFunc 计算成本, 没有 CostFunc 时为 1
+func (c *basicCache) costOf(key, value interface{}) int64 {
+	if c.costFunc != nil {
+		return c.costFunc(key, value)
+	}
+	return 1
+}
+
+// 根据过期时刻计算刷新时刻和保留时刻
+func (c *basicCache) newMeta(expiration *time.Time) itemMeta {
+	meta := itemMeta{expiration: expiration}
+	if expiration == nil {
+		return meta
+	}
+
+	// 没有加载函数时过期的值不会再返回, 不需要刷新时刻和保留窗口
+	if c.loader == nil && c.batcher == nil {
+		return meta
+	}
+	if c.refreshAhead > 0 && c.refreshAhead < 1 {
+		now := time.Now()
+		t := now.Add(time.Duration(float64(expiration.Sub(now)) * c.refreshAhead))
+		meta.refreshAt = &t
+	}
+	if c.staleWindow > 0 {
+		t := expiration.Add(c.staleWindow)
+		meta.deadline = &t
+	}
+	return meta
+}
+
+func (c *basicCache) Get(key interface{}) (interface{}, error) {
+	return c.GetCtx(context.Background(), key)
+}
+
+// ctx 传给反序列化和回调, ctx 已经结束时返回 ctx.Err()
+// 不存在时返回 ErrNotFound, 已过期时返回 ErrExpired, 反序列化失败时返回 ErrCodec
+func (c *basicCache) GetCtx(ctx context.Context, key interface{}) (interface{}, error) {
+	if c.isClosed() {
+		return nil, ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	value, meta, err := c.lookup(ctx, key, now)
+	// 保留窗口内的旧值只通过 GetOrLoad 返回
+	if err == nil && meta.IsExpire(now) {
+		err = ErrExpired
+	}
+	if err != nil {
+		c.countMiss()
+		return nil, err
+	}
+	// 负缓存无论记录的是不存在还是加载失败, 都视为不存在
+	if c.negativeErr(value) != nil {
+		return nil, ErrNotFound
+	}
+	return c.output(ctx, value)
+}
+
+// 读取元素, 元素超出保留窗口被删除时执行过期回调并返回 ErrExpired
+func (c *basicCache) lookup(ctx context.Context, key interface{}, now time.Time) (interface{}, itemMeta, error) {
+	value, meta, err := c.store.getValue(key, now)
+	if err != nil {
+		return nil, meta, err
+	}
+	if meta.isDead(now) {
+		c.notifyCtx(ctx, evictedItem{key, value, EvictExpired, meta.cost})
+		return nil, meta, ErrExpired
+	}
+	return value, meta, nil
+}
+
+func (c *basicCache) TotalCost() int64 {
+	return c.store.totalCost()
+}
+
+// 判断元素数量或总成本是否超出上限
+func (c *basicCache) overflow(count int, cost int64) bool {
+	return (c.capacity > 0 && count > c.capacity) || (c.maxCost > 0 && cost > c.maxCost)
+}
+
+// 清空缓存, 每个元素都会回调 EvictCleared
+func (c *basicCache) Clear() {
+	c.notify(c.store.clear()...)
+}
+
+// 反序列化并记录命中情况, ctx 已经结束时返回 ctx.Err(), 反序列化失败时返回 ErrCodec 并记为 miss
+func (c *basicCache) output(ctx context.Context, value interface{}) (interface{}, error) {
+	value, err := c.decode(ctx, value)
+	if err != nil {
+		c.countMiss()
+		return nil, codecErr(err)
+	}
+
+	if c.flight {
+		if value != nil {
+			(*c.register).IncrHicCount()
+		} else {
+			(*c.register).IncrMissCount()
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (c *basicCache) countMiss() {
+	if c.flight {
+		(*c.register).IncrMissCount()
+	}
+}
+
+// 配置了反序列化时还原存储的值
+func (c *basicCache) decode(ctx context.Context, value interface{}) (interface{}, error) {
+	if c.deserializeFunc == nil || value == nil {
+		return value, nil
+	}
+	return c.deserializeFunc(ctx, value)
+}
+
+// 配置了序列化时转换要存储的值
+func (c *basicCache) serialize(ctx context.Context, value interface{}) (interface{}, error) {
+	if c.serializeFunc == nil {
+		return value, nil
+	}
+	return c.serializeFunc(ctx, value)
+}
+
+// 关闭缓存, 停止后台清理, 可以重复调用
+// 关闭后返回 error 的方法都返回 ErrClosed
+func (c *basicCache) Close() error {
+	atomic.StoreInt32(&c.closed, 1)
+	if c.janitor != nil {
+		c.janitor.stop()
+	}
+	return nil
+}
+
+func (c *basicCache) isClosed() bool {
+	return atomic.LoadInt32(&c.closed) == 1
+}
+
+// 配置了清理间隔时启动后台清理, 需在具体缓存初始化完成后调用
+func (c *basicCache) startJanitor() {
+	if c.cleanupInterval <= 0 {
+		return
+	}
+	c.janitor = newJanitor(c.cleanupInterval)
+	go c.janitor.run(c.deleteExpired)
+}
+
+// 删除过期元素并执行过期回调
+func (c *basicCache) deleteExpired() {
+	c.notify(c.store.deleteExpired(time.Now())...)
 }
@@ -1,6 +1,15 @@
 package localcache
 
 const (
-	LRU = "lru"
-	SIMPLE = "simple"
+	LRU      = "lru"
+	SIMPLE   = "simple"
+	LFU      = "lfu"
+	TINYLFU  = "tinylfu" // W-TinyLFU
+	ARC      = "arc"     // Adaptive Replacement Cache
+	TWOQUEUE = "2q"      // 2Q
+	SLRU     = "slru"    // Segmented LRU
+	CLOCK    = "clock"
+	CLOCKPRO = "clockpro" // CLOCK-Pro
+	S3FIFO   = "s3fifo"   // S3-FIFO
+	GDSF     = "gdsf"     // GreedyDual-Size-Frequency
 )
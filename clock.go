@@ -0,0 +1,100 @@
+package localcache
+
+import "sync/atomic"
+
+// 环形链表上的节点, CLOCK 和 CLOCK-Pro 共用
+type clockNode struct {
+	key        interface{}
+	referenced int32 // 命中时原子置 1, 指针扫过时清零
+	status     int   // CLOCK-Pro 中的页面状态
+	prev, next *clockNode
+}
+
+// 标记被访问过, 已经标记时不再写入, 避免读多时争抢同一个缓存行
+func (n *clockNode) reference() {
+	if atomic.LoadInt32(&n.referenced) == 0 {
+		atomic.StoreInt32(&n.referenced, 1)
+	}
+}
+
+// 清除访问标记, 返回清除之前是否被访问过
+func (n *clockNode) clearReference() bool {
+	return atomic.SwapInt32(&n.referenced, 0) == 1
+}
+
+// 把 n 插入到 at 的前面, 即指针最后才会扫到的位置
+func (n *clockNode) linkBefore(at *clockNode) {
+	n.prev, n.next = at.prev, at
+	at.prev.next = n
+	at.prev = n
+}
+
+func (n *clockNode) unlink() {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	n.prev, n.next = nil, nil
+}
+
+// CLOCK 淘汰策略
+// 命中时只原子地设置访问标记, 不调整链表, 所以读取只需要读锁;
+// 剔除时指针沿环扫描, 清除遇到的访问标记, 剔除第一个没有标记的元素
+type clock struct {
+	nodes map[interface{}]*clockNode
+	hand  *clockNode
+}
+
+func newClock() *clock {
+	return &clock{nodes: make(map[interface{}]*clockNode)}
+}
+
+func (p *clock) ConcurrentAccess() {}
+
+func (p *clock) OnInsert(key interface{}) {
+	if n, ok := p.nodes[key]; ok {
+		n.reference()
+		return
+	}
+
+	n := &clockNode{key: key}
+	p.nodes[key] = n
+	if p.hand == nil {
+		n.prev, n.next = n, n
+		p.hand = n
+		return
+	}
+	n.linkBefore(p.hand)
+}
+
+func (p *clock) OnAccess(key interface{}) {
+	if n, ok := p.nodes[key]; ok {
+		n.reference()
+	}
+}
+
+func (p *clock) OnRemove(key interface{}) {
+	n, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	if p.hand == n {
+		p.hand = n.next
+		if p.hand == n {
+			p.hand = nil
+		}
+	}
+	n.unlink()
+	delete(p.nodes, key)
+}
+
+func (p *clock) Victim() (interface{}, bool) {
+	for p.hand != nil {
+		n := p.hand
+		if n.clearReference() {
+			p.hand = n.next
+			continue
+		}
+		p.OnRemove(n.key)
+		return n.key, true
+	}
+	return nil, false
+}
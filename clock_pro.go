@@ -0,0 +1,193 @@
+package localcache
+
+const clockProColdPercent = 1 // 冷页面目标数量的初始比例
+
+// CLOCK-Pro 中的页面状态
+const (
+	clockCold = iota // 常驻的冷页面
+	clockHot         // 常驻的热页面
+	clockTest        // 已经被剔除的冷页面, 只保存 key, 在测试期内再次写入时直接成为热页面
+)
+
+// CLOCK-Pro 淘汰策略
+// 所有页面在同一个环上, 由三个指针维护: 冷指针剔除没有访问标记的冷页面,
+// 热指针把没有访问标记的热页面降级为冷页面, 测试指针清理过期的测试页面;
+// 测试页面再次写入说明冷页面的空间太小, 测试页面过期说明冷页面的空间太大, 据此调整 coldTarget
+type clockPro struct {
+	capacity   int
+	coldTarget int // 冷页面的目标数量
+	nodes      map[interface{}]*clockNode
+	handHot    *clockNode
+	handCold   *clockNode
+	handTest   *clockNode
+	countHot   int
+	countCold  int
+	countTest  int
+}
+
+func newClockPro(builder *CacheBuilder) *clockPro {
+	p := &clockPro{
+		capacity:   builder.capacity,
+		coldTarget: 1,
+		nodes:      make(map[interface{}]*clockNode),
+	}
+	if c := builder.capacity * clockProColdPercent / 100; c > 1 {
+		p.coldTarget = c
+	}
+	return p
+}
+
+func (p *clockPro) ConcurrentAccess() {}
+
+func (p *clockPro) OnInsert(key interface{}) {
+	n, ok := p.nodes[key]
+	if !ok {
+		p.link(&clockNode{key: key, status: clockCold})
+		p.countCold++
+		return
+	}
+	if n.status != clockTest {
+		n.reference()
+		return
+	}
+
+	// 测试期内再次写入, 扩大冷页面的空间并直接作为热页面放回环上
+	if p.coldTarget < p.limit() {
+		p.coldTarget++
+	}
+	p.unlink(n)
+	p.countTest--
+	n.status = clockHot
+	n.clearReference()
+	p.link(n)
+	p.countHot++
+}
+
+func (p *clockPro) OnAccess(key interface{}) {
+	if n, ok := p.nodes[key]; ok && n.status != clockTest {
+		n.reference()
+	}
+}
+
+func (p *clockPro) OnRemove(key interface{}) {
+	n, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	switch n.status {
+	case clockHot:
+		p.countHot--
+	case clockCold:
+		p.countCold--
+	default:
+		return
+	}
+	p.unlink(n)
+}
+
+func (p *clockPro) Victim() (interface{}, bool) {
+	if p.countHot+p.countCold == 0 {
+		return nil, false
+	}
+	for {
+		if key, ok := p.runHandCold(); ok {
+			return key, true
+		}
+	}
+}
+
+// 冷指针前进一步: 有访问标记的冷页面晋升为热页面, 否则剔除并转为测试页面
+func (p *clockPro) runHandCold() (interface{}, bool) {
+	n := p.handCold
+	p.handCold = n.next
+
+	var key interface{}
+	evicted := false
+	if n.status == clockCold {
+		if n.clearReference() {
+			n.status = clockHot
+			p.countCold--
+			p.countHot++
+		} else {
+			n.status = clockTest
+			p.countCold--
+			p.countTest++
+			key, evicted = n.key, true
+			for p.countTest > p.limit() {
+				p.runHandTest()
+			}
+		}
+	}
+
+	// 没有冷页面时也需要降级, 否则冷指针找不到可以剔除的页面
+	for p.countHot > 0 && (p.countCold == 0 || p.countHot > p.limit()-p.coldTarget) {
+		p.runHandHot()
+	}
+	return key, evicted
+}
+
+// 热指针前进一步: 没有访问标记的热页面降级为冷页面
+func (p *clockPro) runHandHot() {
+	if p.handHot == p.handTest {
+		p.runHandTest()
+	}
+
+	n := p.handHot
+	p.handHot = n.next
+	if n.status == clockHot && !n.clearReference() {
+		n.status = clockCold
+		p.countHot--
+		p.countCold++
+	}
+}
+
+// 测试指针前进一步: 结束测试页面的测试期, 同时缩小冷页面的空间
+func (p *clockPro) runHandTest() {
+	n := p.handTest
+	p.handTest = n.next
+	if n.status == clockTest {
+		p.unlink(n)
+		p.countTest--
+		if p.coldTarget > 1 {
+			p.coldTarget--
+		}
+	}
+}
+
+// 容量, 没有设置容量时以当前常驻页面的数量为准
+func (p *clockPro) limit() int {
+	if p.capacity > 0 {
+		return p.capacity
+	}
+	return p.countHot + p.countCold
+}
+
+// 新页面放在热指针的前面
+func (p *clockPro) link(n *clockNode) {
+	p.nodes[n.key] = n
+	if p.handHot == nil {
+		n.prev, n.next = n, n
+		p.handHot, p.handCold, p.handTest = n, n, n
+		return
+	}
+	n.linkBefore(p.handHot)
+}
+
+// 从环上移除, 指向该页面的指针移动到下一个页面
+func (p *clockPro) unlink(n *clockNode) {
+	next := n.next
+	if next == n {
+		next = nil
+	}
+	if p.handHot == n {
+		p.handHot = next
+	}
+	if p.handCold == n {
+		p.handCold = next
+	}
+	if p.handTest == n {
+		p.handTest = next
+	}
+	n.unlink()
+	delete(p.nodes, n.key)
+}
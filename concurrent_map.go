@@ -1,18 +1,318 @@
 package localcache
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
+// 分段缓存, 按 key 的 hash 选择分段, 每个分段独立加锁并运行配置的淘汰策略
 type ConcurrentMap struct {
-	Table []*Node // node 节点表
-	mu 	sync.Mutex
+	segments []Cache // 分段表, 长度为 2 的幂
+	mask     int
+	batcher  *batcher // 所有分段共享, 不同分段的未命中也能合并到一个批次
 }
 
-func (m *ConcurrentMap) casTabAt(node *Node) bool {
-	m.mu.Lock()
-	m.mu.Unlock()
+// 根据 key 的 hash 找到对应的分段
+func (m *ConcurrentMap) segmentFor(key interface{}) Cache {
+	return m.segments[m.segmentIndex(key)]
+}
+
+func (m *ConcurrentMap) segmentIndex(key interface{}) int {
+	return hash(key) & m.mask
+}
+
+func (m *ConcurrentMap) Set(key, value interface{}) error {
+	return m.segmentFor(key).Set(key, value)
+}
+
+func (m *ConcurrentMap) SetWithTTL(key, value interface{}, ttl time.Duration) error {
+	return m.segmentFor(key).SetWithTTL(key, value, ttl)
+}
+
+func (m *ConcurrentMap) SetWithExpireAt(key, value interface{}, expireAt time.Time) error {
+	return m.segmentFor(key).SetWithExpireAt(key, value, expireAt)
+}
+
+func (m *ConcurrentMap) SetWithCost(key, value interface{}, cost int64) error {
+	return m.segmentFor(key).SetWithCost(key, value, cost)
+}
+
+func (m *ConcurrentMap) SetWithOptions(key, value interface{}, opts Options) error {
+	return m.segmentFor(key).SetWithOptions(key, value, opts)
+}
+
+func (m *ConcurrentMap) Get(key interface{}) (interface{}, error) {
+	return m.segmentFor(key).Get(key)
+}
+
+func (m *ConcurrentMap) GetCtx(ctx context.Context, key interface{}) (interface{}, error) {
+	return m.segmentFor(key).GetCtx(ctx, key)
+}
+
+func (m *ConcurrentMap) SetCtx(ctx context.Context, key, value interface{}) error {
+	return m.segmentFor(key).SetCtx(ctx, key, value)
+}
+
+func (m *ConcurrentMap) GetOrLoad(ctx context.Context, key interface{}) (interface{}, error) {
+	return m.segmentFor(key).GetOrLoad(ctx, key)
+}
+
+// 共享的批量加载器直接处理所有分段的未命中, 没有时各分段同时加载
+func (m *ConcurrentMap) GetManyOrLoad(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error) {
+	if m.segments[0].(segment).isClosed() {
+		return map[interface{}]interface{}{}, ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return map[interface{}]interface{}{}, err
+	}
+
+	if m.batcher == nil {
+		groups := m.groupKeys(keys)
+		found := make([]map[interface{}]interface{}, len(groups))
+		errs := make([]error, len(groups))
+		var wg sync.WaitGroup
+		for i, group := range groups {
+			if len(group) == 0 {
+				continue
+			}
+			wg.Add(1)
+			go func(i int, group []interface{}) {
+				defer wg.Done()
+				found[i], errs[i] = m.segments[i].GetManyOrLoad(ctx, group)
+			}(i, group)
+		}
+		wg.Wait()
+
+		values := make(map[interface{}]interface{}, len(keys))
+		var firstErr error
+		for i := range groups {
+			for k, v := range found[i] {
+				values[k] = v
+			}
+			if errs[i] != nil && firstErr == nil {
+				firstErr = errs[i]
+			}
+		}
+		return values, firstErr
+	}
+
+	values := make(map[interface{}]interface{}, len(keys))
+	var missing []interface{}
+	var firstErr error
+	for i, group := range m.groupKeys(keys) {
+		if len(group) == 0 {
+			continue
+		}
+		found, notFound, failures := m.segments[i].(segment).getMany(group)
+		for k, v := range found {
+			values[k] = v
+		}
+		notFound, err := skipFailures(notFound, failures)
+		missing = append(missing, notFound...)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(missing) == 0 {
+		return values, firstErr
+	}
+
+	loaded, err := m.batcher.load(ctx, missing)
+	for k, v := range loaded {
+		values[k] = v
+	}
+	if firstErr == nil {
+		firstErr = err
+	}
+	return values, firstErr
+}
+
+// 负缓存写入 key 所在的分段
+func (m *ConcurrentMap) cacheFailure(key interface{}, err error) {
+	m.segmentFor(key).(segment).cacheFailure(key, err)
+}
+
+func (m *ConcurrentMap) SetIfAbsent(key, value interface{}) (bool, error) {
+	return m.segmentFor(key).SetIfAbsent(key, value)
+}
+
+func (m *ConcurrentMap) Replace(key, value interface{}) (bool, error) {
+	return m.segmentFor(key).Replace(key, value)
+}
+
+func (m *ConcurrentMap) CompareAndSwap(key, old, new interface{}) (bool, error) {
+	return m.segmentFor(key).CompareAndSwap(key, old, new)
+}
 
-	return true
+func (m *ConcurrentMap) GetAndDelete(key interface{}) (interface{}, error) {
+	return m.segmentFor(key).GetAndDelete(key)
 }
 
+func (m *ConcurrentMap) Compute(key interface{}, fn ComputeFunc) (interface{}, error) {
+	return m.segmentFor(key).Compute(key, fn)
+}
+
+func (m *ConcurrentMap) IncrBy(key interface{}, delta int64) (int64, error) {
+	return m.segmentFor(key).IncrBy(key, delta)
+}
+
+func (m *ConcurrentMap) incrBy(key interface{}, delta int64, like interface{}) (int64, error) {
+	return m.segmentFor(key).(typedCounter).incrBy(key, delta, like)
+}
+
+func (m *ConcurrentMap) DecrBy(key interface{}, delta int64) (int64, error) {
+	return m.segmentFor(key).DecrBy(key, delta)
+}
+
+func (m *ConcurrentMap) Remove(key interface{}) error {
+	return m.segmentFor(key).Remove(key)
+}
+
+// 按分段分组, 每个分段只加一次锁
+func (m *ConcurrentMap) GetMany(keys []interface{}) (map[interface{}]interface{}, []interface{}) {
+	values := make(map[interface{}]interface{}, len(keys))
+	for i, group := range m.groupKeys(keys) {
+		if len(group) == 0 {
+			continue
+		}
+		found, _ := m.segments[i].GetMany(group)
+		for k, v := range found {
+			values[k] = v
+		}
+	}
+
+	var missing []interface{}
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return values, missing
+}
+
+func (m *ConcurrentMap) SetMany(items map[interface{}]interface{}) error {
+	groups := make([]map[interface{}]interface{}, len(m.segments))
+	for k, v := range items {
+		i := m.segmentIndex(k)
+		if groups[i] == nil {
+			groups[i] = make(map[interface{}]interface{})
+		}
+		groups[i][k] = v
+	}
+
+	var firstErr error
+	for i, group := range groups {
+		if group == nil {
+			continue
+		}
+		if err := m.segments[i].SetMany(group); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (m *ConcurrentMap) RemoveMany(keys []interface{}) int {
+	count := 0
+	for i, group := range m.groupKeys(keys) {
+		if len(group) > 0 {
+			count += m.segments[i].RemoveMany(group)
+		}
+	}
+	return count
+}
+
+// 按 key 所在的分段分组
+func (m *ConcurrentMap) groupKeys(keys []interface{}) [][]interface{} {
+	groups := make([][]interface{}, len(m.segments))
+	for _, key := range keys {
+		i := m.segmentIndex(key)
+		groups[i] = append(groups[i], key)
+	}
+	return groups
+}
+
+func (m *ConcurrentMap) GetAll() map[interface{}]interface{} {
+	items := make(map[interface{}]interface{})
+	for _, segment := range m.segments {
+		for k, v := range segment.GetAll() {
+			items[k] = v
+		}
+	}
+	return items
+}
+
+func (m *ConcurrentMap) KeyCount() int {
+	count := 0
+	for _, segment := range m.segments {
+		count += segment.KeyCount()
+	}
+	return count
+}
+
+func (m *ConcurrentMap) TotalCost() int64 {
+	var cost int64
+	for _, segment := range m.segments {
+		cost += segment.TotalCost()
+	}
+	return cost
+}
+
+func (m *ConcurrentMap) Has(key interface{}) bool {
+	return m.segmentFor(key).Has(key)
+}
+
+// 清空所有分段
+func (m *ConcurrentMap) Clear() {
+	for _, segment := range m.segments {
+		segment.Clear()
+	}
+}
+
+// 关闭所有分段
+func (m *ConcurrentMap) Close() error {
+	for _, segment := range m.segments {
+		segment.Close()
+	}
+	return nil
+}
+
+// 分段数向上取到 2 的幂, 但不超过容量和总成本上限, 保证每个分段至少能放下一个元素
+// 容量和总成本上限按分段取整后, 余数分给前面的分段, 各分段之和等于配置的值
+func newConcurrentMap(builder *CacheBuilder) *ConcurrentMap {
+	n := 1
+	for n < builder.shards {
+		n <<= 1
+	}
+	for n > 1 && ((builder.capacity > 0 && n > builder.capacity) || (builder.maxCost > 0 && int64(n) > builder.maxCost)) {
+		n >>= 1
+	}
+
+	m := &ConcurrentMap{
+		segments: make([]Cache, n),
+		mask:     n - 1,
+	}
+	segmentBuilder := *builder
+	segmentBuilder.shards = 0
+	if builder.batchLoader != nil {
+		m.batcher = newBatcher(builder, m.SetMany, m.cacheFailure)
+		segmentBuilder.batcher = m.batcher
+	}
+	for i := range m.segments {
+		if builder.capacity > 0 {
+			segmentBuilder.capacity = builder.capacity / n
+			if i < builder.capacity%n {
+				segmentBuilder.capacity++
+			}
+		}
+		if builder.maxCost > 0 {
+			segmentBuilder.maxCost = builder.maxCost / int64(n)
+			if int64(i) < builder.maxCost%int64(n) {
+				segmentBuilder.maxCost++
+			}
+		}
+		m.segments[i] = segmentBuilder.build()
+	}
+	return m
+}
@@ -0,0 +1,111 @@
+package localcache
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// 原子地给整数加上 delta 并返回新值
+// 不存在时以 delta 作为初始值, 和 Set 一样使用 SetDuration 设置的过期时间; 已经存在时保留原来的过期时刻
+// 当前值不是整数时返回 ErrValueType, 超出当前值类型的范围时返回 ErrOverflow
+// 新值保持当前值的类型, 配置了 SerializeFunc 时新建的计数以十进制字符串保存
+func (c *basicCache) IncrBy(key interface{}, delta int64) (int64, error) {
+	return c.incrBy(key, delta, nil)
+}
+
+// 支持指定新建计数的类型
+type typedCounter interface {
+	incrBy(key interface{}, delta int64, like interface{}) (int64, error)
+}
+
+// like 不为 nil 时新建的计数使用 like 的类型, 不是整数或字符串类型时返回 ErrValueType
+func (c *basicCache) incrBy(key interface{}, delta int64, like interface{}) (int64, error) {
+	var ret int64
+	err := c.compute(key, func(old interface{}, exists bool) (interface{}, computeOp, error) {
+		if !exists {
+			ret = delta
+			if like != nil {
+				value, err := fromInt64(delta, like)
+				if err != nil {
+					return nil, computeNone, err
+				}
+				return value, computeSet, nil
+			}
+			if c.serializeFunc != nil {
+				return strconv.FormatInt(delta, 10), computeSet, nil
+			}
+			return delta, computeSet, nil
+		}
+
+		n, err := toInt64(old)
+		if err != nil {
+			return nil, computeNone, err
+		}
+		if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+			return nil, computeNone, ErrOverflow
+		}
+
+		value, err := fromInt64(n+delta, old)
+		if err != nil {
+			return nil, computeNone, err
+		}
+		ret = n + delta
+		return value, computeUpdate, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return ret, nil
+}
+
+// 原子地给整数减去 delta 并返回新值, 规则同 IncrBy
+func (c *basicCache) DecrBy(key interface{}, delta int64) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, ErrOverflow
+	}
+	return c.IncrBy(key, -delta)
+}
+
+// 把整数或十进制字符串转换为 int64
+func toInt64(value interface{}) (int64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return 0, ErrOverflow
+		}
+		return int64(v.Uint()), nil
+	case reflect.String:
+		n, err := strconv.ParseInt(v.String(), 10, 64)
+		if err != nil {
+			return 0, ErrValueType
+		}
+		return n, nil
+	}
+	return 0, ErrValueType
+}
+
+// 把 n 转换为和 like 相同的类型
+func fromInt64(n int64, like interface{}) (interface{}, error) {
+	v := reflect.New(reflect.TypeOf(like)).Elem()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.OverflowInt(n) {
+			return nil, ErrOverflow
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n < 0 || v.OverflowUint(uint64(n)) {
+			return nil, ErrOverflow
+		}
+		v.SetUint(uint64(n))
+	case reflect.String:
+		v.SetString(strconv.FormatInt(n, 10))
+	default:
+		return nil, ErrValueType
+	}
+	return v.Interface(), nil
+}
@@ -0,0 +1,238 @@
+package localcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// 默认的最长等待时间
+const defaultBatchWait = time.Millisecond
+
+// 合并并发调用方的未命中, 攒成一批后调用一次 BatchLoaderFunc
+// 批次在第一个 key 进入后等待 maxWait, 或者 key 数量达到 maxSize 时执行
+// 同一个 key 已经在批次中时直接等待该批次, 不会重复加载
+type batcher struct {
+	fn       BatchLoaderFunc
+	maxSize  int                                           // 每批最多的 key 数量, 小于等于 0 表示不限制
+	maxWait  time.Duration                                 // 第一个 key 进入后最多等待的时间
+	save     func(items map[interface{}]interface{}) error // 写入加载结果
+	fail     func(key interface{}, err error)              // 记录没有加载到的 key, 用于负缓存
+	register *RegisterAccessor                             // 计数器, 没有启动飞行器时为 nil
+
+	mu       sync.Mutex
+	pending  *batch                 // 正在收集的批次
+	inflight map[interface{}]*batch // key 所在的批次, 包括收集中和执行中的
+}
+
+// 一次批量加载
+type batch struct {
+	ctx    context.Context // 开启批次的调用方的 ctx, 保留其中的值但不会被取消
+	keys   []interface{}
+	done   chan struct{} // 加载完成后关闭
+	values map[interface{}]interface{}
+	err    error
+}
+
+func newBatcher(builder *CacheBuilder, save func(items map[interface{}]interface{}) error, fail func(key interface{}, err error)) *batcher {
+	b := &batcher{
+		fn:       builder.batchLoader,
+		maxSize:  builder.maxBatchSize,
+		maxWait:  builder.maxBatchWait,
+		save:     save,
+		fail:     fail,
+		inflight: make(map[interface{}]*batch),
+	}
+	if b.maxWait <= 0 {
+		b.maxWait = defaultBatchWait
+	}
+	if builder.flight {
+		b.register = builder.register
+	}
+	return b
+}
+
+// 加载 keys, 返回加载到的值, BatchLoaderFunc 没有返回的 key 不在结果中
+// ctx 结束时立即返回 ctx.Err(), 批次仍会在后台执行完并写入缓存
+func (b *batcher) load(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error) {
+	owners := make(map[interface{}]*batch, len(keys))
+
+	b.mu.Lock()
+	for _, key := range keys {
+		if _, ok := owners[key]; ok {
+			continue
+		}
+		if bt, ok := b.inflight[key]; ok {
+			owners[key] = bt
+			continue
+		}
+
+		if b.pending == nil {
+			bt := &batch{ctx: detach(ctx), done: make(chan struct{})}
+			b.pending = bt
+			time.AfterFunc(b.maxWait, func() { b.dispatch(bt) })
+		}
+		bt := b.pending
+		bt.keys = append(bt.keys, key)
+		b.inflight[key] = bt
+		owners[key] = bt
+
+		if b.maxSize > 0 && len(bt.keys) >= b.maxSize {
+			b.pending = nil
+			go b.run(bt)
+		}
+	}
+	b.mu.Unlock()
+
+	values := make(map[interface{}]interface{}, len(keys))
+	var firstErr error
+	for key, bt := range owners {
+		select {
+		case <-bt.done:
+		case <-ctx.Done():
+			return values, ctx.Err()
+		}
+
+		if bt.err != nil {
+			if firstErr == nil {
+				firstErr = bt.err
+			}
+			continue
+		}
+		if value, ok := bt.values[key]; ok {
+			values[key] = value
+		}
+	}
+	return values, firstErr
+}
+
+// 等待时间到达后执行, 批次已经因为数量达到上限执行过时忽略
+func (b *batcher) dispatch(bt *batch) {
+	b.mu.Lock()
+	if b.pending != bt {
+		b.mu.Unlock()
+		return
+	}
+	b.pending = nil
+	b.mu.Unlock()
+
+	b.run(bt)
+}
+
+// 批次合并了多个调用方, 使用开启批次的调用方的 ctx 调用 BatchLoaderFunc, 任何调用方取消都不会中断批次
+// 先写入缓存再移出 inflight, 保证之后的查找一定能命中, 只写入批次中请求的 key
+func (b *batcher) run(bt *batch) {
+	start := time.Now()
+	bt.values, bt.err = b.fn(bt.ctx, bt.keys)
+	if b.register != nil {
+		(*b.register).AddLoadTime(time.Since(start))
+		if bt.err != nil {
+			(*b.register).IncrLoadFailureCount()
+		} else {
+			(*b.register).IncrLoadSuccessCount()
+		}
+	}
+	if bt.err == nil && len(bt.values) > 0 {
+		requested := make(map[interface{}]interface{}, len(bt.keys))
+		for _, key := range bt.keys {
+			if value, ok := bt.values[key]; ok {
+				requested[key] = value
+			}
+		}
+		b.save(requested)
+	}
+	for _, key := range bt.keys {
+		if bt.err != nil {
+			b.fail(key, bt.err)
+		} else if _, ok := bt.values[key]; !ok {
+			b.fail(key, KeyNotFoundError)
+		}
+	}
+
+	b.mu.Lock()
+	for _, key := range bt.keys {
+		if b.inflight[key] == bt {
+			delete(b.inflight, key)
+		}
+	}
+	b.mu.Unlock()
+	close(bt.done)
+}
+
+// 批量读取, 未命中的 key 通过加载函数加载并写入缓存
+// 配置了 BatchLoader 时未命中的 key 和其他调用方的合并成批次加载, 否则同时对每个 key 调用 Loader
+// 加载失败时返回已经得到的值和第一个错误, 加载函数没有返回的 key 不在结果中
+// 命中负缓存的 key 不会加载, 记录的是加载失败时同样返回该错误
+func (c *basicCache) GetManyOrLoad(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error) {
+	if c.isClosed() {
+		return map[interface{}]interface{}{}, ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return map[interface{}]interface{}{}, err
+	}
+	values, missing, failures := c.getMany(keys)
+	missing, firstErr := skipFailures(missing, failures)
+	if len(missing) == 0 {
+		return values, firstErr
+	}
+
+	if c.batcher != nil {
+		loaded, err := c.batcher.load(ctx, missing)
+		for k, v := range loaded {
+			values[k] = v
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		return values, firstErr
+	}
+	if c.loader == nil {
+		return values, firstErr
+	}
+
+	loaded := make([]interface{}, len(missing))
+	errs := make([]error, len(missing))
+	var wg sync.WaitGroup
+	for i, key := range missing {
+		wg.Add(1)
+		go func(i int, key interface{}) {
+			defer wg.Done()
+			loaded[i], errs[i] = c.loads.do(ctx, key, func(ctx context.Context) (interface{}, error) {
+				return c.load(ctx, key)
+			})
+		}(i, key)
+	}
+	wg.Wait()
+
+	for i, key := range missing {
+		// 和批量加载一致, 不存在的 key 只是不在结果中
+		if errs[i] != nil {
+			if firstErr == nil && !errors.Is(errs[i], ErrNotFound) {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		values[key] = loaded[i]
+	}
+	return values, firstErr
+}
+
+// 去掉命中负缓存的 key, 返回需要加载的 key 和第一个记录的加载错误
+func skipFailures(missing []interface{}, failures map[interface{}]error) ([]interface{}, error) {
+	if len(failures) == 0 {
+		return missing, nil
+	}
+
+	var firstErr error
+	loadable := make([]interface{}, 0, len(missing)-len(failures))
+	for _, key := range missing {
+		err, ok := failures[key]
+		if !ok {
+			loadable = append(loadable, key)
+		} else if firstErr == nil && !errors.Is(err, KeyNotFoundError) {
+			firstErr = err
+		}
+	}
+	return loadable, firstErr
+}
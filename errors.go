@@ -0,0 +1,50 @@
+package localcache
+
+import (
+	"context"
+	"errors"
+)
+
+// 所有缓存类型的错误约定, 通过 errors.Is 判断:
+//   - 不存在: Get、GetCtx、Remove、GetAndDelete 返回 ErrNotFound, 负缓存同样视为不存在
+//   - 已过期: Get、GetCtx 读到过期的 key 时返回 ErrExpired, 它同时满足 errors.Is(err, ErrNotFound)
+//   - 序列化失败: 写入时的序列化和读取时的反序列化失败返回 ErrCodec, 可以继续通过 errors.Is 和 errors.As 匹配原始错误
+//   - 已关闭: Close 之后返回 error 的方法都返回 ErrClosed, 其余方法照常执行
+//   - 已满: 单个元素的成本超过 MaxCost (分段时为分段的上限), 或者 SIMPLE 配置为 OverflowReject 时写入新 key, 返回 ErrCacheFull
+//   - 类型不符: GenericCache 读到的值不是 V, 或者 IncrBy、DecrBy 的当前值不是整数时返回 ErrValueType
+//   - 溢出: IncrBy、DecrBy 的结果超出当前值类型的范围时返回 ErrOverflow
+var (
+	ErrNotFound  = errors.New("key not found .")
+	ErrExpired   = &expiredError{}
+	ErrCodec     = errors.New("codec error .")
+	ErrClosed    = errors.New("cache is closed .")
+	ErrCacheFull = errors.New("cache is full .")
+	ErrValueType = errors.New("value type mismatch .")
+	ErrOverflow  = errors.New("integer overflow .")
+)
+
+// 兼容旧的名称, 和 ErrNotFound 是同一个值
+var KeyNotFoundError = ErrNotFound
+
+// 过期属于不存在的一种
+type expiredError struct{}
+
+func (e *expiredError) Error() string        { return "key expired ." }
+func (e *expiredError) Is(target error) bool { return target == ErrNotFound }
+
+// 序列化或反序列化失败, 保留原始错误
+type codecError struct {
+	err error
+}
+
+func (e *codecError) Error() string        { return "codec error: " + e.err.Error() }
+func (e *codecError) Is(target error) bool { return target == ErrCodec }
+func (e *codecError) Unwrap() error        { return e.err }
+
+// 包装序列化函数返回的错误, ctx 的错误原样返回
+func codecErr(err error) error {
+	if err == nil || errors.Is(err, ErrCodec) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return &codecError{err: err}
+}
@@ -0,0 +1,62 @@
+package localcache
+
+import "context"
+
+// 元素被移出缓存的原因
+type EvictReason int
+
+const (
+	EvictExpired  EvictReason = iota + 1 // 过期
+	EvictCapacity                        // 超出容量被剔除
+	EvictRemoved                         // 调用 Remove 删除
+	EvictReplaced                        // 被新值覆盖
+	EvictCleared                         // 调用 Clear 清空
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictExpired:
+		return "expired"
+	case EvictCapacity:
+		return "capacity"
+	case EvictRemoved:
+		return "removed"
+	case EvictReplaced:
+		return "replaced"
+	case EvictCleared:
+		return "cleared"
+	}
+	return "unknown"
+}
+
+// 被移出缓存的元素, 由具体存储在持有锁时收集, 释放锁后统一回调
+type evictedItem struct {
+	key    interface{}
+	value  interface{}
+	reason EvictReason
+	cost   int64
+}
+
+// 执行回调, 调用方不能持有锁, 回调中可以再次操作缓存
+// 负缓存只更新成本, 不回调
+func (c *basicCache) notify(items ...evictedItem) {
+	c.notifyCtx(context.Background(), items...)
+}
+
+// 同 notify, ctx 传给回调
+func (c *basicCache) notifyCtx(ctx context.Context, items ...evictedItem) {
+	for _, item := range items {
+		if c.flight {
+			(*c.register).AddCost(-item.cost)
+		}
+		if isNegative(item.value) {
+			continue
+		}
+		if item.reason == EvictExpired && c.expireFunc != nil {
+			c.expireFunc()
+		}
+		if c.evictionCallback != nil {
+			c.evictionCallback(ctx, item.key, item.value, item.reason)
+		}
+	}
+}
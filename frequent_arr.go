@@ -19,13 +19,15 @@ func (f *FreqArr) Swap(i, j int) {
 
 func (f *FreqArr) Push(x interface{}) {
 	value := x.(*LFUItem)
+	value.Index = len(*f) // 放入的时候设置 index
 	*f = append(*f, value)
-	value.Index = (*f).Len() // 放入的时候设置 index
 }
 
 func (f *FreqArr) Pop() interface{} {
 	n := len(*f)
 	ret := (*f)[n-1]
+	(*f)[n-1] = nil
+	ret.Index = -1 // 已经不在堆中
 	*f = (*f)[:n-1]
 	return ret
 }
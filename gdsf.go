@@ -0,0 +1,113 @@
+package localcache
+
+import "container/heap"
+
+// GreedyDual-Size-Frequency 淘汰策略
+// 优先级 = L + 访问次数 * 未命中代价 / 大小, 剔除优先级最低的元素, 即每字节价值最低的元素;
+// L 是最近一次剔除的优先级, 新元素的优先级从 L 开始, 让长期没有访问的旧元素逐渐老化
+type gdsf struct {
+	entries gdsfHeap
+	nodes   map[interface{}]*gdsfEntry
+	clock   float64 // L
+}
+
+type gdsfEntry struct {
+	key      interface{}
+	freq     int64
+	size     int64
+	missCost int64
+	priority float64
+	index    int
+}
+
+func newGDSF() *gdsf {
+	return &gdsf{nodes: make(map[interface{}]*gdsfEntry)}
+}
+
+func (p *gdsf) OnInsert(key interface{}) {
+	if _, ok := p.nodes[key]; ok {
+		p.OnAccess(key)
+		return
+	}
+	e := &gdsfEntry{key: key, size: 1, missCost: 1}
+	p.nodes[key] = e
+	heap.Push(&p.entries, e)
+	p.touch(e)
+}
+
+func (p *gdsf) OnAccess(key interface{}) {
+	if e, ok := p.nodes[key]; ok {
+		p.touch(e)
+	}
+}
+
+func (p *gdsf) OnResize(key interface{}, size, missCost int64) {
+	e, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	if size > 0 {
+		e.size = size
+	}
+	if missCost > 0 {
+		e.missCost = missCost
+	}
+	e.priority = p.priority(e)
+	heap.Fix(&p.entries, e.index)
+}
+
+func (p *gdsf) OnRemove(key interface{}) {
+	if e, ok := p.nodes[key]; ok {
+		heap.Remove(&p.entries, e.index)
+		delete(p.nodes, key)
+	}
+}
+
+func (p *gdsf) Victim() (interface{}, bool) {
+	if p.entries.Len() == 0 {
+		return nil, false
+	}
+	e := heap.Pop(&p.entries).(*gdsfEntry)
+	delete(p.nodes, e.key)
+	p.clock = e.priority
+	return e.key, true
+}
+
+// 增加访问次数并重新计算优先级
+func (p *gdsf) touch(e *gdsfEntry) {
+	e.freq++
+	e.priority = p.priority(e)
+	heap.Fix(&p.entries, e.index)
+}
+
+func (p *gdsf) priority(e *gdsfEntry) float64 {
+	return p.clock + float64(e.freq)*float64(e.missCost)/float64(e.size)
+}
+
+// 按优先级排列的最小堆
+type gdsfHeap []*gdsfEntry
+
+func (h gdsfHeap) Len() int           { return len(h) }
+func (h gdsfHeap) Less(i, j int) bool { return h[i].priority < h[j].priority }
+
+func (h gdsfHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *gdsfHeap) Push(x interface{}) {
+	e := x.(*gdsfEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *gdsfHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*h = old[:n-1]
+	return e
+}
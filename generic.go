@@ -0,0 +1,439 @@
+package localcache
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// 泛型缓存, 在 Cache 之上做类型安全的封装, 支持所有缓存类型
+type GenericCache[K comparable, V any] struct {
+	cache Cache
+}
+
+// 泛型组织器
+type GenericCacheBuilder[K comparable, V any] struct {
+	builder *CacheBuilder
+}
+
+// 创建一个泛型构造器
+func CreateGeneric[K comparable, V any]() *GenericCacheBuilder[K, V] {
+	return &GenericCacheBuilder[K, V]{
+		builder: Create(),
+	}
+}
+
+func (b *GenericCacheBuilder[K, V]) Tp(tp string) *GenericCacheBuilder[K, V] {
+	b.builder.Tp(tp)
+	return b
+}
+
+// 开启后台清理
+func (b *GenericCacheBuilder[K, V]) CleanupInterval(interval time.Duration) *GenericCacheBuilder[K, V] {
+	b.builder.CleanupInterval(interval)
+	return b
+}
+
+// 设置加载函数, ttl 为 0 时使用 SetDuration 设置的过期时间
+func (b *GenericCacheBuilder[K, V]) Loader(fc func(ctx context.Context, key K) (V, time.Duration, error)) *GenericCacheBuilder[K, V] {
+	b.builder.Loader(func(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+		return fc(ctx, key.(K))
+	})
+	return b
+}
+
+// 设置批量加载函数, 并发的未命中合并成批次加载
+func (b *GenericCacheBuilder[K, V]) BatchLoader(fc func(ctx context.Context, keys []K) (map[K]V, error)) *GenericCacheBuilder[K, V] {
+	b.builder.BatchLoader(func(ctx context.Context, keys []interface{}) (map[interface{}]interface{}, error) {
+		typed := make([]K, len(keys))
+		for i, key := range keys {
+			typed[i] = key.(K)
+		}
+		loaded, err := fc(ctx, typed)
+		values := make(map[interface{}]interface{}, len(loaded))
+		for k, v := range loaded {
+			values[k] = v
+		}
+		return values, err
+	})
+	return b
+}
+
+// 每批最多的 key 数量, 小于等于 0 表示不限制
+func (b *GenericCacheBuilder[K, V]) MaxBatchSize(n int) *GenericCacheBuilder[K, V] {
+	b.builder.MaxBatchSize(n)
+	return b
+}
+
+// 批次在第一个 key 进入后最多等待的时间
+func (b *GenericCacheBuilder[K, V]) MaxBatchWait(wait time.Duration) *GenericCacheBuilder[K, V] {
+	b.builder.MaxBatchWait(wait)
+	return b
+}
+
+// 在 ttl 内记住加载函数给出的不存在结果
+func (b *GenericCacheBuilder[K, V]) NegativeTTL(ttl time.Duration) *GenericCacheBuilder[K, V] {
+	b.builder.NegativeTTL(ttl)
+	return b
+}
+
+// 在 backoff 内记住加载失败的错误
+func (b *GenericCacheBuilder[K, V]) ErrorBackoff(backoff time.Duration) *GenericCacheBuilder[K, V] {
+	b.builder.ErrorBackoff(backoff)
+	return b
+}
+
+// 存活时间过去 fraction 比例后在后台重新加载
+func (b *GenericCacheBuilder[K, V]) RefreshAhead(fraction float64) *GenericCacheBuilder[K, V] {
+	b.builder.RefreshAhead(fraction)
+	return b
+}
+
+// 过期后的 window 时间内继续返回旧值并在后台重新加载
+func (b *GenericCacheBuilder[K, V]) StaleWhileRevalidate(window time.Duration) *GenericCacheBuilder[K, V] {
+	b.builder.StaleWhileRevalidate(window)
+	return b
+}
+
+// 设置容量
+func (b *GenericCacheBuilder[K, V]) Capacity(capacity int) *GenericCacheBuilder[K, V] {
+	b.builder.Capacity(capacity)
+	return b
+}
+
+// 分段存储
+func (b *GenericCacheBuilder[K, V]) Shards(n int) *GenericCacheBuilder[K, V] {
+	b.builder.Shards(n)
+	return b
+}
+
+func (b *GenericCacheBuilder[K, V]) SetDuration(duration time.Duration) *GenericCacheBuilder[K, V] {
+	b.builder.SetDuration(duration)
+	return b
+}
+
+// 组织序列化, 序列化函数作用于底层存储的值
+func (b *GenericCacheBuilder[K, V]) SerializeFunc(fc SerializeFunc) *GenericCacheBuilder[K, V] {
+	b.builder.SerializeFunc(fc)
+	return b
+}
+
+// 组织反序列化, 反序列化的结果需要是 V 类型
+func (b *GenericCacheBuilder[K, V]) DeserializeFunc(fc DeserializeFunc) *GenericCacheBuilder[K, V] {
+	b.builder.DeserializeFunc(fc)
+	return b
+}
+
+// 带 ctx 的序列化
+func (b *GenericCacheBuilder[K, V]) SerializeCtxFunc(fc SerializeCtxFunc) *GenericCacheBuilder[K, V] {
+	b.builder.SerializeCtxFunc(fc)
+	return b
+}
+
+// 带 ctx 的反序列化, 反序列化的结果需要是 V 类型
+func (b *GenericCacheBuilder[K, V]) DeserializeCtxFunc(fc DeserializeCtxFunc) *GenericCacheBuilder[K, V] {
+	b.builder.DeserializeCtxFunc(fc)
+	return b
+}
+
+// 带 ctx 的写入回调
+func (b *GenericCacheBuilder[K, V]) AddCtxCallback(fc AddCtxCallback) *GenericCacheBuilder[K, V] {
+	b.builder.AddCtxCallback(fc)
+	return b
+}
+
+// 带 ctx 的移出回调
+func (b *GenericCacheBuilder[K, V]) EvictionCtxCallback(fc EvictionCtxCallback) *GenericCacheBuilder[K, V] {
+	b.builder.EvictionCtxCallback(fc)
+	return b
+}
+
+// 加入元素后的回调, 配置了序列化时收到的是序列化后的值
+func (b *GenericCacheBuilder[K, V]) AddCallback(fc ADDCallback) *GenericCacheBuilder[K, V] {
+	b.builder.AddCallback(fc)
+	return b
+}
+
+func (b *GenericCacheBuilder[K, V]) ExpireFunc(fc ExpireFunc) *GenericCacheBuilder[K, V] {
+	b.builder.ExpireFunc(fc)
+	return b
+}
+
+// 元素移出缓存后的回调, 配置了序列化时收到的是序列化后的值
+func (b *GenericCacheBuilder[K, V]) EvictionCallback(fc EvictionCallback) *GenericCacheBuilder[K, V] {
+	b.builder.EvictionCallback(fc)
+	return b
+}
+
+// 设置总成本上限
+func (b *GenericCacheBuilder[K, V]) MaxCost(maxCost int64) *GenericCacheBuilder[K, V] {
+	b.builder.MaxCost(maxCost)
+	return b
+}
+
+// 设置成本计算函数
+func (b *GenericCacheBuilder[K, V]) CostFunc(fc func(key K, value V) int64) *GenericCacheBuilder[K, V] {
+	b.builder.CostFunc(func(key, value interface{}) int64 {
+		return fc(key.(K), value.(V))
+	})
+	return b
+}
+
+// 设置 SIMPLE 超出容量或总成本时的处理方式
+func (b *GenericCacheBuilder[K, V]) Overflow(strategy OverflowStrategy) *GenericCacheBuilder[K, V] {
+	b.builder.Overflow(strategy)
+	return b
+}
+
+// 使用自定义的淘汰策略
+func (b *GenericCacheBuilder[K, V]) Policy(factory PolicyFactory) *GenericCacheBuilder[K, V] {
+	b.builder.Policy(factory)
+	return b
+}
+
+// 设置 2Q 的 A1in 和 A1out 占容量的比例
+func (b *GenericCacheBuilder[K, V]) TwoQueueRatio(recent, ghost float64) *GenericCacheBuilder[K, V] {
+	b.builder.TwoQueueRatio(recent, ghost)
+	return b
+}
+
+// 设置 SLRU 保护段占容量的比例
+func (b *GenericCacheBuilder[K, V]) SLRUProtectedRatio(ratio float64) *GenericCacheBuilder[K, V] {
+	b.builder.SLRUProtectedRatio(ratio)
+	return b
+}
+
+// 启动飞行器
+func (b *GenericCacheBuilder[K, V]) OpenFlight(r *RegisterAccessor) *GenericCacheBuilder[K, V] {
+	b.builder.OpenFlight(r)
+	return b
+}
+
+func (b *GenericCacheBuilder[K, V]) Build() *GenericCache[K, V] {
+	cache := b.builder.Build()
+	if cache == nil {
+		return nil
+	}
+	return NewGenericCache[K, V](cache)
+}
+
+// 把已有的 Cache 包装成泛型缓存
+func NewGenericCache[K comparable, V any](cache Cache) *GenericCache[K, V] {
+	return &GenericCache[K, V]{cache: cache}
+}
+
+func (c *GenericCache[K, V]) Set(key K, value V) error {
+	return c.cache.Set(key, value)
+}
+
+// 写入并指定存活时间, 0 表示永不过期
+func (c *GenericCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) error {
+	return c.cache.SetWithTTL(key, value, ttl)
+}
+
+// 写入并指定过期时刻, 零值表示永不过期
+func (c *GenericCache[K, V]) SetWithExpireAt(key K, value V, expireAt time.Time) error {
+	return c.cache.SetWithExpireAt(key, value, expireAt)
+}
+
+// 写入并指定成本
+func (c *GenericCache[K, V]) SetWithCost(key K, value V, cost int64) error {
+	return c.cache.SetWithCost(key, value, cost)
+}
+
+// 写入并指定大小和未命中代价
+func (c *GenericCache[K, V]) SetWithOptions(key K, value V, opts Options) error {
+	return c.cache.SetWithOptions(key, value, opts)
+}
+
+// 不存在时返回 ErrNotFound, 已过期时返回 ErrExpired
+func (c *GenericCache[K, V]) Get(key K) (V, error) {
+	return c.typed(c.cache.Get(key))
+}
+
+// ctx 结束时返回 ctx.Err()
+func (c *GenericCache[K, V]) GetCtx(ctx context.Context, key K) (V, error) {
+	return c.typed(c.cache.GetCtx(ctx, key))
+}
+
+// ctx 结束时不再写入
+func (c *GenericCache[K, V]) SetCtx(ctx context.Context, key K, value V) error {
+	return c.cache.SetCtx(ctx, key, value)
+}
+
+// 未命中时通过 loader 加载
+func (c *GenericCache[K, V]) GetOrLoad(ctx context.Context, key K) (V, error) {
+	return c.typed(c.cache.GetOrLoad(ctx, key))
+}
+
+// 批量读取, 未命中的 key 通过加载函数加载, 类型不匹配的值会被忽略
+func (c *GenericCache[K, V]) GetManyOrLoad(ctx context.Context, keys []K) (map[K]V, error) {
+	raw := make([]interface{}, len(keys))
+	for i, key := range keys {
+		raw[i] = key
+	}
+
+	found, err := c.cache.GetManyOrLoad(ctx, raw)
+	values := make(map[K]V, len(found))
+	for k, v := range found {
+		if value, ok := v.(V); ok {
+			values[k.(K)] = value
+		}
+	}
+	return values, err
+}
+
+// 把底层返回的值转换为 V
+func (c *GenericCache[K, V]) typed(value interface{}, err error) (V, error) {
+	var ret V
+	if err != nil {
+		return ret, err
+	}
+	if value == nil {
+		return ret, ErrNotFound
+	}
+
+	ret, ok := value.(V)
+	if !ok {
+		return ret, ErrValueType
+	}
+	return ret, nil
+}
+
+// 不存在时写入, 返回是否写入
+func (c *GenericCache[K, V]) SetIfAbsent(key K, value V) (bool, error) {
+	return c.cache.SetIfAbsent(key, value)
+}
+
+// 存在时覆盖, 返回是否写入
+func (c *GenericCache[K, V]) Replace(key K, value V) (bool, error) {
+	return c.cache.Replace(key, value)
+}
+
+// 当前值等于 old 时写入 new, 返回是否写入
+func (c *GenericCache[K, V]) CompareAndSwap(key K, old, new V) (bool, error) {
+	return c.cache.CompareAndSwap(key, old, new)
+}
+
+// 读取并删除, 不存在时返回 ErrNotFound
+func (c *GenericCache[K, V]) GetAndDelete(key K) (V, error) {
+	return c.typed(c.cache.GetAndDelete(key))
+}
+
+// 根据当前值计算新值, keep 为 false 时删除并返回零值, 当前值类型不匹配时视为不存在
+func (c *GenericCache[K, V]) Compute(key K, fn func(old V, exists bool) (V, bool)) (V, error) {
+	var ret V
+	value, err := c.cache.Compute(key, func(old interface{}, exists bool) (interface{}, bool) {
+		typed, ok := old.(V)
+		return fn(typed, exists && ok)
+	})
+	if err != nil || value == nil {
+		return ret, err
+	}
+	return value.(V), nil
+}
+
+// 原子地加上 delta 并返回新值, 新建的计数保存为 V 类型, V 不是整数或字符串类型时返回 ErrValueType
+func (c *GenericCache[K, V]) IncrBy(key K, delta int64) (int64, error) {
+	var like interface{} = *new(V)
+	if counter, ok := c.cache.(typedCounter); ok && like != nil {
+		return counter.incrBy(key, delta, like)
+	}
+	return c.cache.IncrBy(key, delta)
+}
+
+// 原子地减去 delta 并返回新值, 规则同 IncrBy
+func (c *GenericCache[K, V]) DecrBy(key K, delta int64) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, ErrOverflow
+	}
+	return c.IncrBy(key, -delta)
+}
+
+// 批量读取, 返回命中的值和未命中的 key, 类型不匹配的值视为未命中
+func (c *GenericCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	raw := make([]interface{}, len(keys))
+	for i, key := range keys {
+		raw[i] = key
+	}
+
+	found, _ := c.cache.GetMany(raw)
+	values := make(map[K]V, len(found))
+	var missing []K
+	for _, key := range keys {
+		if value, ok := found[key].(V); ok {
+			values[key] = value
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	return values, missing
+}
+
+// 批量写入
+func (c *GenericCache[K, V]) SetMany(items map[K]V) error {
+	raw := make(map[interface{}]interface{}, len(items))
+	for k, v := range items {
+		raw[k] = v
+	}
+	return c.cache.SetMany(raw)
+}
+
+// 批量删除, 返回删除的数量
+func (c *GenericCache[K, V]) RemoveMany(keys []K) int {
+	raw := make([]interface{}, len(keys))
+	for i, key := range keys {
+		raw[i] = key
+	}
+	return c.cache.RemoveMany(raw)
+}
+
+func (c *GenericCache[K, V]) Remove(key K) error {
+	return c.cache.Remove(key)
+}
+
+// 获取所有, 类型不匹配的值会被忽略
+func (c *GenericCache[K, V]) GetAll() map[K]V {
+	all := c.cache.GetAll()
+	items := make(map[K]V, len(all))
+	for k, v := range all {
+		key, ok := k.(K)
+		if !ok {
+			continue
+		}
+		value, ok := v.(V)
+		if !ok {
+			continue
+		}
+		items[key] = value
+	}
+	return items
+}
+
+func (c *GenericCache[K, V]) KeyCount() int {
+	return c.cache.KeyCount()
+}
+
+func (c *GenericCache[K, V]) Has(key K) bool {
+	return c.cache.Has(key)
+}
+
+// 当前总成本
+func (c *GenericCache[K, V]) TotalCost() int64 {
+	return c.cache.TotalCost()
+}
+
+// 清空缓存
+func (c *GenericCache[K, V]) Clear() {
+	c.cache.Clear()
+}
+
+// 关闭缓存, 停止后台清理
+func (c *GenericCache[K, V]) Close() error {
+	return c.cache.Close()
+}
+
+// 返回底层的 Cache
+func (c *GenericCache[K, V]) Unwrap() Cache {
+	return c.cache
+}
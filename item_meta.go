@@ -0,0 +1,38 @@
+package localcache
+
+import "time"
+
+// 元素的过期信息, 嵌入到各个缓存的元素中
+type itemMeta struct {
+	expiration *time.Time // 过期时刻, nil 表示永不过期
+	refreshAt  *time.Time // 到达后异步刷新, 需要配置 loader
+	deadline   *time.Time // 过期后仍保留到该时刻, 期间可以返回旧值
+	cost       int64      // 成本, 用于按总成本淘汰
+	missCost   int64      // 未命中时重新获取的代价
+}
+
+func (m *itemMeta) SetExpire(duration time.Duration) {
+	t := time.Now().Add(duration)
+	m.expiration = &t
+}
+
+// 判断是否超时
+func (m *itemMeta) IsExpire(now time.Time) bool {
+	if m.expiration == nil {
+		return false
+	}
+	return m.expiration.Before(now)
+}
+
+// 判断是否需要真正删除, 没有保留窗口时和过期一致
+func (m *itemMeta) isDead(now time.Time) bool {
+	if m.deadline != nil {
+		return m.deadline.Before(now)
+	}
+	return m.IsExpire(now)
+}
+
+// 判断是否需要提前刷新
+func (m *itemMeta) needRefresh(now time.Time) bool {
+	return m.refreshAt != nil && m.refreshAt.Before(now)
+}
@@ -0,0 +1,41 @@
+package localcache
+
+import (
+	"sync"
+	"time"
+)
+
+// 后台清理器, 按固定间隔执行清理函数
+type janitor struct {
+	interval time.Duration
+	done     chan struct{}
+	once     sync.Once
+}
+
+func newJanitor(interval time.Duration) *janitor {
+	return &janitor{
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+func (j *janitor) run(clean func()) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			clean()
+		case <-j.done:
+			return
+		}
+	}
+}
+
+// 停止清理, 可以重复调用
+func (j *janitor) stop() {
+	j.once.Do(func() {
+		close(j.done)
+	})
+}
@@ -1,220 +1,77 @@
 package localcache
 
-import (
-	"sync"
-	"time"
-)
-
-type LFUCache struct {
-	basicCache
-	items map[interface{}]*LFUItem
-	FreqArr
-}
+import "container/heap"
 
+// 按访问次数排列的堆中的元素, 值和过期信息由 PolicyCache 保存
 type LFUItem struct {
-	Key        interface{}
-	Value      interface{}
-	Weight     uint32
-	Index      int // 在分片的位置
-	expiration *time.Time
-	mu         sync.RWMutex
+	itemMeta
+	Key    interface{}
+	Value  interface{}
+	Weight uint32 // 访问次数
+	Index  int    // 在堆中的位置
 }
 
-func (c *LFUCache) Set(key, value interface{}) error {
-	var err error
-	if c.serializeFunc != nil {
-		value, err = c.serializeFunc(value)
-		if err != nil {
-			return err
-		}
-	}
-	//err = c.setValue(key, value)
-
-	if c.addCallback != nil {
-		c.addCallback(key, value)
-	}
-	return err
+// LFU 淘汰策略, 剔除访问次数最少的元素, 覆盖写入也算一次访问
+// 刚写入的元素在本次写入的剔除中最后考虑, 避免新元素被立即剔除
+type lfu struct {
+	freqArr FreqArr // 按访问次数排列的小顶堆
+	nodes   map[interface{}]*LFUItem
+	fresh   *LFUItem // 刚写入还没有进入堆的元素
 }
 
-/*func (c *LFUCache) setValue(key, value interface{}) error {
-	c.basicCache.mu.Lock()
-	item, ok := c.items[key]
-	if !ok {
-		newItem := &LFUItem{
-			key:   key,
-			value: value,
-
-		}
-		c.items[key] = c.evictList.PushFront(newItem)
-		item = c.items[key]
-		c.basicCache.mu.Unlock()
-
-		if c.isEvict() {
-			c.evictItems()
-		}
-	} else {
-		c.basicCache.mu.Unlock()
-		c.evictList.MoveToFront(item)
-	}
-
-	originItem := item.Value.(*LRUItem)
-
-	originItem.mu.Lock()
-	defer originItem.mu.Unlock()
-
-	originItem.value = value
-	if c.basicCache.duration != nil {
-		originItem.SetExpire(*c.duration)
-	}
-	return nil
+func newLFU() *lfu {
+	return &lfu{nodes: make(map[interface{}]*LFUItem)}
 }
 
-func (c *LRUCache) Get(key interface{}) (interface{}, error) {
-	value, err := c.getValue(key)
-	if err != nil {
-		return nil, err
+func (p *lfu) OnInsert(key interface{}) {
+	if _, ok := p.nodes[key]; ok {
+		p.OnAccess(key)
+		return
 	}
-
-	if c.deserializeFunc != nil {
-		value, _ = c.basicCache.deserializeFunc(value)
-	}
-
-	if c.flight {
-		if value != nil {
-			(*c.register).IncrHicCount()
-		} else {
-			(*c.register).IncrMissCount()
-		}
-	}
-
-	return value, nil
+	p.settle()
+	p.fresh = &LFUItem{Key: key, Weight: 1, Index: -1}
+	p.nodes[key] = p.fresh
 }
 
-func (c *LRUCache) getValue(key interface{}) (interface{}, error) {
-	c.basicCache.mu.Lock()
-	item, ok := c.items[key]
-	c.basicCache.mu.Unlock()
-
-	if !ok {
-		return nil, KeyNotFoundError
-	}
-
-	originItem := item.Value.(*LRUItem)
-	originItem.mu.RLock()
-	defer originItem.mu.RUnlock()
-
-	ret := originItem.value
-	if originItem.IsExpire(time.Now()) {
-		ret = nil
-		delete(c.items, key)
-
-		c.evictList.Remove(item)
-		if c.expireFunc != nil {
-			c.expireFunc()
-		}
-	} else {
-		c.evictList.MoveToFront(item)
+func (p *lfu) OnAccess(key interface{}) {
+	p.settle()
+	if item, ok := p.nodes[key]; ok {
+		item.Weight++
+		heap.Fix(&p.freqArr, item.Index)
 	}
-
-	return ret, nil
 }
 
-func (c *LRUCache) Remove(key interface{}) error {
-	c.basicCache.mu.Lock()
-	item, ok := c.items[key]
-	c.basicCache.mu.Unlock()
-
+func (p *lfu) OnRemove(key interface{}) {
+	item, ok := p.nodes[key]
 	if !ok {
-		return KeyNotFoundError
-	} else {
-		c.removeValue(item)
+		return
 	}
-	return nil
-}
-
-func (c *LRUCache) removeValue(item *list.Element) error {
-	originItem := item.Value.(*LRUItem)
-	originItem.mu.Lock()
-	defer originItem.mu.Unlock()
-
-	delete(c.items, originItem.key)
-	c.evictList.Remove(item)
-
-	item = nil
-	return nil
-}
-
-func (c *LRUCache) GetAll() map[interface{}]interface{} {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	items := make(map[interface{}]interface{}, len(c.items))
-
-	for k, item := range c.items {
-		if c.Has(k) {
-			items[k] = item.Value.(*LRUItem).value
-		}
+	delete(p.nodes, key)
+	if item == p.fresh {
+		p.fresh = nil
+		return
 	}
-	return items
+	heap.Remove(&p.freqArr, item.Index)
 }
 
-func (c *LRUCache) KeyCount() int {
-	return len(c.items)
-}
-
-func (c *LRUCache) Has(key interface{}) bool {
-	item, ok := c.items[key]
-	if !ok {
-		return false
+func (p *lfu) Victim() (interface{}, bool) {
+	if p.freqArr.Len() > 0 {
+		item := heap.Pop(&p.freqArr).(*LFUItem)
+		delete(p.nodes, item.Key)
+		return item.Key, true
 	}
-	originItem := item.Value.(*LRUItem)
-	return !originItem.IsExpire(time.Now())
-}
-
-// 判断是否过载
-func (c *LRUCache) isEvict() bool {
-	return (c.basicCache.capacity > 0 && len(c.items) > c.basicCache.capacity)
-}
-
-// 剔除超过容量的数据
-func (c *LRUCache) evictItems() {
-	over := c.KeyCount() - c.basicCache.capacity
-	if over > 0 {
-		for i := 0; i < over; i++ {
-			item := c.evictList.Back()
-			c.removeValue(item)
-		}
+	if p.fresh != nil {
+		key := p.fresh.Key
+		p.OnRemove(key)
+		return key, true
 	}
+	return nil, false
 }
 
-func (it *LRUItem) SetExpire(duration time.Duration) {
-	t := time.Now().Add(duration)
-	it.expiration = &t
-}
-
-func (it *LRUItem) IsExpire(now time.Time) bool {
-	if it.expiration == nil {
-		return false
+// 上一次写入的元素放入堆中
+func (p *lfu) settle() {
+	if p.fresh != nil {
+		heap.Push(&p.freqArr, p.fresh)
+		p.fresh = nil
 	}
-
-	if &now == nil {
-		return false
-	}
-	return it.expiration.Before(now)
-}
-
-// new a LRU cache
-func newLRUCache(builder *CacheBuilder) *LRUCache {
-	cache := &LRUCache{}
-	buildCache(&cache.basicCache, builder)
-
-	cache.init()
-	return cache
 }
-
-// init this cache
-func (c *LFUCache) init() {
-	c.items = make(map[interface{}]*LFUItem, c.capacity)
-	 heap.Init(&c.freqArr)
-	c.evictList = list.New()
-}*/
@@ -0,0 +1,128 @@
+package localcache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// 命中时直接返回, 未命中时调用 loader 加载并写入缓存
+// 命中负缓存时直接返回记录的错误, 不调用 loader
+// 同一个 key 的并发未命中只会触发一次加载, 共享同一个结果
+// 到达刷新时刻或处于保留窗口内时返回当前值, 并在后台重新加载
+// ctx 传给反序列化和回调, ctx 结束时不再等待加载, 立即返回 ctx.Err(), 其他等待同一次加载的调用方不受影响
+// loader 收到的 ctx 保留其中的值, 所有等待的调用方都放弃后被取消
+func (c *basicCache) GetOrLoad(ctx context.Context, key interface{}) (interface{}, error) {
+	if c.loader == nil && c.batcher == nil {
+		return c.GetCtx(ctx, key)
+	}
+	if c.isClosed() {
+		return nil, ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	value, meta, err := c.lookup(ctx, key, now)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if err := c.negativeErr(value); err != nil {
+		return nil, err
+	}
+
+	if value != nil {
+		if !meta.IsExpire(now) {
+			if meta.needRefresh(now) {
+				c.refresh(ctx, key)
+			}
+			return c.output(ctx, value)
+		}
+		if c.staleWindow > 0 {
+			c.refresh(ctx, key)
+			return c.output(ctx, value)
+		}
+	}
+
+	if c.flight {
+		(*c.register).IncrMissCount()
+	}
+	if c.loader == nil {
+		return c.loadBatch(ctx, key)
+	}
+	return c.loads.do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return c.load(ctx, key)
+	})
+}
+
+// 在后台重新加载, 同一个 key 已经在加载时忽略
+// 后台加载不受调用方取消的影响, 但保留 ctx 中的值
+func (c *basicCache) refresh(ctx context.Context, key interface{}) {
+	ctx = detach(ctx)
+	if c.loader == nil {
+		go c.batcher.load(ctx, []interface{}{key})
+		return
+	}
+	c.loads.doAsync(key, func() (interface{}, error) {
+		return c.load(ctx, key)
+	})
+}
+
+// 调用 loader 并写入缓存, 失败时按配置写入负缓存
+// ctx 被取消导致的失败不会被记住
+func (c *basicCache) load(ctx context.Context, key interface{}) (interface{}, error) {
+	start := time.Now()
+	value, ttl, err := c.loader(ctx, key)
+	if c.flight {
+		(*c.register).AddLoadTime(time.Since(start))
+		if err != nil {
+			(*c.register).IncrLoadFailureCount()
+		} else {
+			(*c.register).IncrLoadSuccessCount()
+		}
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			c.cacheFailure(key, err)
+		}
+		return nil, err
+	}
+
+	// 加载结果对之后的调用方同样有用, 所有调用方都放弃等待后仍然写入
+
+	expiration := c.defaultExpiration()
+	if ttl != 0 {
+		expiration = c.expirationAfter(ttl)
+	}
+	if err := c.set(detach(ctx), key, value, expiration, 0, 0); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// 只配置了批量加载函数时, 单个 key 也加入批次, 没有加载到时返回 KeyNotFoundError
+func (c *basicCache) loadBatch(ctx context.Context, key interface{}) (interface{}, error) {
+	values, err := c.batcher.load(ctx, []interface{}{key})
+	if err != nil {
+		return nil, err
+	}
+	value, ok := values[key]
+	if !ok {
+		return nil, KeyNotFoundError
+	}
+	return value, nil
+}
+
+// 保留 ctx 中的值, 但不会被取消, 也没有截止时间
+type detachedContext struct {
+	context.Context
+}
+
+func detach(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
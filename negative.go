@@ -0,0 +1,66 @@
+package localcache
+
+import (
+	"errors"
+	"time"
+)
+
+// 负缓存的占位值, 记录加载函数给出的不存在或失败结果
+// 直接写入存储, 不经过序列化, 也不触发写入和移出回调
+type negative struct {
+	err error // KeyNotFoundError 表示不存在, 其他为加载失败的错误
+}
+
+func isNegative(value interface{}) bool {
+	_, ok := value.(*negative)
+	return ok
+}
+
+// 分段缓存需要调用的 basicCache 内部操作
+type segment interface {
+	getMany(keys []interface{}) (map[interface{}]interface{}, []interface{}, map[interface{}]error)
+	cacheFailure(key interface{}, err error)
+	isClosed() bool
+}
+
+// 按配置记住一次加载失败, 不存在时保留 NegativeTTL, 其他错误保留 ErrorBackoff, 为 0 时不记录
+// 加载失败时不会覆盖还能返回的旧值, 比如后台刷新失败时保留窗口内的值
+func (c *basicCache) cacheFailure(key interface{}, err error) {
+	notFound := errors.Is(err, KeyNotFoundError)
+	ttl := c.errorBackoff
+	if notFound {
+		ttl = c.negativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	now := time.Now()
+	t := now.Add(ttl)
+	meta := itemMeta{expiration: &t, cost: 1, missCost: 1}
+
+	c.mu.Lock()
+	if stored, old, ok := c.store.peek(key); ok && !notFound && !isNegative(stored) && !old.isDead(now) {
+		c.mu.Unlock()
+		return
+	}
+	evicted, setErr := c.store.put(key, &negative{err: err}, meta)
+	c.mu.Unlock()
+
+	c.notify(evicted...)
+	if setErr == nil && c.flight {
+		(*c.register).AddCost(meta.cost)
+	}
+}
+
+// 命中负缓存时记录并返回保存的错误, 不是负缓存时返回 nil
+func (c *basicCache) negativeErr(value interface{}) error {
+	neg, ok := value.(*negative)
+	if !ok {
+		return nil
+	}
+	if c.flight {
+		(*c.register).IncrNegativeHitCount()
+	}
+	return neg.err
+}
@@ -1,8 +1,8 @@
 package localcache
 
 import (
-	"bytes"
-	"encoding/gob"
+	"math"
+	"reflect"
 	"sync"
 )
 
@@ -23,23 +23,113 @@ func (n *Node) HashCode() int {
 }
 
 func hash(raw interface{}) int {
-	data, ok := raw.([]byte)
-	if !ok {
-		var buf bytes.Buffer
-		enc := gob.NewEncoder(&buf)
-		err := enc.Encode(raw)
-		if err != nil {
-			return 0
+	switch k := raw.(type) {
+	case string:
+		return hashString(k)
+	case []byte:
+		return hashBytes(k)
+	case int:
+		return hashUint64(uint64(k))
+	case int32:
+		return hashUint64(uint64(k))
+	case int64:
+		return hashUint64(uint64(k))
+	case uint:
+		return hashUint64(uint64(k))
+	case uint32:
+		return hashUint64(uint64(k))
+	case uint64:
+		return hashUint64(k)
+	}
+
+	// 其他类型按 == 的语义计算, 比如 type UserID int64、结构体和指针
+	return spread(hashValue(0, reflect.ValueOf(raw)))
+}
+
+// 相等的值一定得到相同的结果: 指针和 channel 按地址, -0 和 0 相同,
+// 结构体和数组逐个元素计算 (包括未导出字段), 接口按动态值计算
+func hashValue(h int, v reflect.Value) int {
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		for i := 0; i < len(s); i++ {
+			h = 31*h + int(s[i])
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		h = mixUint64(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		h = mixUint64(h, v.Uint())
+	case reflect.Bool:
+		if v.Bool() {
+			h = mixUint64(h, 1)
+		} else {
+			h = mixUint64(h, 0)
+		}
+	case reflect.Float32, reflect.Float64:
+		h = mixFloat(h, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		h = mixFloat(mixFloat(h, real(c)), imag(c))
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		h = mixUint64(h, uint64(v.Pointer()))
+	case reflect.Interface:
+		if !v.IsNil() {
+			h = hashValue(h, v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			h = hashValue(h, v.Field(i))
 		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			h = hashValue(h, v.Index(i))
+		}
+	}
+	return h
+}
 
-		data = buf.Bytes()
+func mixUint64(h int, v uint64) int {
+	for i := 0; i < 8; i++ {
+		h = 31*h + int(v&0xff)
+		v >>= 8
 	}
+	return h
+}
 
+// -0 和 0 相等, 需要得到相同的结果
+func mixFloat(h int, f float64) int {
+	if f == 0 {
+		f = 0
+	}
+	return mixUint64(h, math.Float64bits(f))
+}
+
+func hashBytes(data []byte) int {
 	var h = 0
-	for d := range data {
-		h = 31*h + (d & 0xff)
+	for _, d := range data {
+		h = 31*h + int(d)
 	}
-	return h
+	return spread(h)
+}
+
+// 字符串直接按字节计算, 避免转换成 []byte 的内存分配
+func hashString(s string) int {
+	var h = 0
+	for i := 0; i < len(s); i++ {
+		h = 31*h + int(s[i])
+	}
+	return spread(h)
+}
+
+// 高位参与运算, 让低位分布更均匀
+func spread(h int) int {
+	h ^= h >> 16
+	return h & math.MaxInt32
+}
+
+// 整数按 8 个字节计算
+func hashUint64(v uint64) int {
+	return spread(mixUint64(0, v))
 }
 
 // 创造一个 node 节点
@@ -0,0 +1,271 @@
+package localcache
+
+import "time"
+
+// 淘汰策略, 只负责决定剔除顺序, 存储、过期、成本和回调由 PolicyCache 负责
+// 所有方法都在缓存的写锁内调用, 实现不需要自己加锁
+// 自定义策略通过 CacheBuilder.Policy 设置
+type EvictionPolicy interface {
+	OnInsert(key interface{})    // 新元素写入
+	OnAccess(key interface{})    // 元素被读取或覆盖写入
+	OnRemove(key interface{})    // 元素被删除、过期或清空
+	Victim() (interface{}, bool) // 选出一个需要剔除的元素并从策略中移除, 没有时返回 false
+}
+
+// 可以在读锁内调用 OnAccess 的策略, 命中时只需要读锁
+// 多个读取会并发调用 OnAccess, 实现需要保证其中的写入是原子的
+// ConcurrentAccess 只用于标记, 不会被调用
+type ConcurrentPolicy interface {
+	EvictionPolicy
+	ConcurrentAccess()
+}
+
+// 需要知道元素大小和未命中代价的策略, 每次写入时在 OnInsert 或 OnAccess 之后调用 OnResize
+type SizeAwarePolicy interface {
+	EvictionPolicy
+	OnResize(key interface{}, size, missCost int64)
+}
+
+// 基于淘汰策略的缓存, 超出容量或总成本时向策略索要需要剔除的元素
+type PolicyCache struct {
+	basicCache
+	items      map[interface{}]*policyItem
+	policy     EvictionPolicy
+	cost       int64 // 当前总成本
+	readAccess bool  // 策略支持在读锁内记录访问
+}
+
+type policyItem struct {
+	itemMeta
+	key   interface{}
+	value interface{}
+}
+
+func (c *PolicyCache) setValue(key, value interface{}, meta itemMeta) ([]evictedItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.put(key, value, meta)
+}
+
+// 调用方需持有锁
+func (c *PolicyCache) put(key, value interface{}, meta itemMeta) ([]evictedItem, error) {
+	var evicted []evictedItem
+	item, ok := c.items[key]
+	if ok {
+		evicted = append(evicted, evictedItem{key, item.value, EvictReplaced, item.cost})
+		c.cost -= item.cost
+		c.policy.OnAccess(key)
+	} else {
+		item = &policyItem{key: key}
+		c.items[key] = item
+		c.policy.OnInsert(key)
+	}
+
+	if sized, ok := c.policy.(SizeAwarePolicy); ok {
+		sized.OnResize(key, meta.cost, meta.missCost)
+	}
+
+	item.value = value
+	item.itemMeta = meta
+	c.cost += meta.cost
+
+	return append(evicted, c.evictItems()...), nil
+}
+
+func (c *PolicyCache) getValue(key interface{}, now time.Time) (interface{}, itemMeta, error) {
+	if c.readAccess {
+		c.mu.RLock()
+		item, ok := c.items[key]
+		if !ok {
+			c.mu.RUnlock()
+			return nil, itemMeta{}, KeyNotFoundError
+		}
+		if !item.isDead(now) {
+			c.policy.OnAccess(key)
+			value, meta := item.value, item.itemMeta
+			c.mu.RUnlock()
+			return value, meta, nil
+		}
+		// 需要删除已经失效的元素, 换成写锁重新查找
+		c.mu.RUnlock()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.get(key, now)
+}
+
+// 调用方需持有锁
+func (c *PolicyCache) get(key interface{}, now time.Time) (interface{}, itemMeta, error) {
+	item, ok := c.items[key]
+	if !ok {
+		return nil, itemMeta{}, KeyNotFoundError
+	}
+
+	if item.isDead(now) {
+		c.removeItem(item)
+	} else {
+		c.policy.OnAccess(key)
+	}
+	return item.value, item.itemMeta, nil
+}
+
+func (c *PolicyCache) Remove(key interface{}) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+
+	c.mu.Lock()
+	item, ok := c.remove(key)
+	c.mu.Unlock()
+
+	if !ok {
+		return KeyNotFoundError
+	}
+	c.notify(item)
+	return nil
+}
+
+// 调用方需持有读锁, 只在策略支持时使用
+func (c *PolicyCache) getShared(key interface{}, now time.Time) (interface{}, itemMeta, bool) {
+	item, ok := c.items[key]
+	if !ok {
+		return nil, itemMeta{}, false
+	}
+	if item.isDead(now) {
+		return nil, itemMeta{}, true
+	}
+	c.policy.OnAccess(key)
+	return item.value, item.itemMeta, false
+}
+
+// 调用方需持有锁
+func (c *PolicyCache) peek(key interface{}) (interface{}, itemMeta, bool) {
+	item, ok := c.items[key]
+	if !ok {
+		return nil, itemMeta{}, false
+	}
+	return item.value, item.itemMeta, true
+}
+
+// 调用方需持有锁
+func (c *PolicyCache) remove(key interface{}) (evictedItem, bool) {
+	item, ok := c.items[key]
+	if !ok {
+		return evictedItem{}, false
+	}
+	c.removeItem(item)
+	return evictedItem{key, item.value, EvictRemoved, item.cost}, true
+}
+
+// 从 map 和策略中同时移除, 调用方需持有锁
+func (c *PolicyCache) removeItem(item *policyItem) {
+	delete(c.items, item.key)
+	c.policy.OnRemove(item.key)
+	c.cost -= item.cost
+}
+
+// 向策略索要需要剔除的元素, 直到不再超出容量和总成本, 调用方需持有锁
+func (c *PolicyCache) evictItems() []evictedItem {
+	var evicted []evictedItem
+	for c.overflow(len(c.items), c.cost) {
+		key, ok := c.policy.Victim()
+		if !ok {
+			break
+		}
+		item, ok := c.items[key]
+		if !ok {
+			continue
+		}
+
+		delete(c.items, key)
+		c.cost -= item.cost
+		evicted = append(evicted, evictedItem{key, item.value, EvictCapacity, item.cost})
+	}
+	return evicted
+}
+
+func (c *PolicyCache) GetAll() map[interface{}]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	items := make(map[interface{}]interface{}, len(c.items))
+	for k, item := range c.items {
+		if !item.IsExpire(now) && !isNegative(item.value) {
+			items[k] = item.value
+		}
+	}
+	return items
+}
+
+func (c *PolicyCache) KeyCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.items)
+}
+
+func (c *PolicyCache) Has(key interface{}) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	return !item.IsExpire(time.Now()) && !isNegative(item.value)
+}
+
+// 删除所有过期元素
+func (c *PolicyCache) deleteExpired(now time.Time) []evictedItem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var evicted []evictedItem
+	for key, item := range c.items {
+		if item.isDead(now) {
+			c.removeItem(item)
+			evicted = append(evicted, evictedItem{key, item.value, EvictExpired, item.cost})
+		}
+	}
+	return evicted
+}
+
+// 清空所有元素
+func (c *PolicyCache) clear() []evictedItem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	evicted := make([]evictedItem, 0, len(c.items))
+	for key, item := range c.items {
+		c.policy.OnRemove(key)
+		evicted = append(evicted, evictedItem{key, item.value, EvictCleared, item.cost})
+	}
+	c.items = make(map[interface{}]*policyItem, c.capacity)
+	c.cost = 0
+	return evicted
+}
+
+func (c *PolicyCache) totalCost() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cost
+}
+
+// new a cache driven by the eviction policy
+func newPolicyCache(builder *CacheBuilder, policy EvictionPolicy) *PolicyCache {
+	cache := &PolicyCache{policy: policy}
+	_, cache.readAccess = policy.(ConcurrentPolicy)
+	buildCache(&cache.basicCache, builder, cache)
+	if cache.readAccess {
+		cache.shared = cache
+	}
+
+	cache.items = make(map[interface{}]*policyItem, cache.capacity)
+	cache.startJanitor()
+	return cache
+}
@@ -1,10 +1,20 @@
 package localcache
 
-import "sync/atomic"
+import (
+	"sync/atomic"
+	"time"
+)
 
 type Register struct {
-	hitCount  int32 // 命中数
-	missCount int32 // miss 数
+	hitCount         int32 // 命中数
+	missCount        int32 // miss 数
+	negativeHitCount int32 // 命中负缓存数
+	loadSuccessCount int32 // 加载成功数
+	loadFailureCount int32 // 加载失败数
+	totalLoadTime    int64 // 加载总耗时, 纳秒
+	totalCost        int64 // 当前总成本
+	admitCount       int32 // 准入数
+	rejectCount      int32 // 拒绝数
 }
 
 type RegisterAccessor interface {
@@ -14,6 +24,28 @@ type RegisterAccessor interface {
 	TotalCount() int32
 	IncrHicCount() int32
 	IncrMissCount() int32
+	AddHitCount(n int32) int32
+	AddMissCount(n int32) int32
+
+	NegativeHitCount() int32
+	IncrNegativeHitCount() int32
+	AddNegativeHitCount(n int32) int32
+
+	LoadSuccessCount() int32
+	LoadFailureCount() int32
+	TotalLoadTime() time.Duration
+	AverageLoadTime() time.Duration
+	IncrLoadSuccessCount() int32
+	IncrLoadFailureCount() int32
+	AddLoadTime(d time.Duration) time.Duration
+
+	TotalCost() int64
+	AddCost(delta int64) int64
+
+	AdmitCount() int32
+	RejectCount() int32
+	IncrAdmitCount() int32
+	IncrRejectCount() int32
 }
 
 func CreateRegister() RegisterAccessor {
@@ -34,10 +66,31 @@ func (r *Register) IncrMissCount() int32 {
 	return atomic.AddInt32(&r.missCount, 1)
 }
 
+func (r *Register) AddHitCount(n int32) int32 {
+	return atomic.AddInt32(&r.hitCount, n)
+}
+
+func (r *Register) AddMissCount(n int32) int32 {
+	return atomic.AddInt32(&r.missCount, n)
+}
+
 func (r *Register) MissCount() int32 {
 	return atomic.LoadInt32(&r.missCount)
 }
 
+// 命中负缓存, 不计入命中数和 miss 数
+func (r *Register) IncrNegativeHitCount() int32 {
+	return atomic.AddInt32(&r.negativeHitCount, 1)
+}
+
+func (r *Register) AddNegativeHitCount(n int32) int32 {
+	return atomic.AddInt32(&r.negativeHitCount, n)
+}
+
+func (r *Register) NegativeHitCount() int32 {
+	return atomic.LoadInt32(&r.negativeHitCount)
+}
+
 func (r *Register) HitRate() float32 {
 	hc, mc := r.HitCount(), r.MissCount()
 	total := hc + mc
@@ -51,3 +104,64 @@ func (r *Register) TotalCount() int32 {
 	hc, mc := r.HitCount(), r.MissCount()
 	return hc + mc
 }
+
+func (r *Register) IncrLoadSuccessCount() int32 {
+	return atomic.AddInt32(&r.loadSuccessCount, 1)
+}
+
+func (r *Register) LoadSuccessCount() int32 {
+	return atomic.LoadInt32(&r.loadSuccessCount)
+}
+
+func (r *Register) IncrLoadFailureCount() int32 {
+	return atomic.AddInt32(&r.loadFailureCount, 1)
+}
+
+func (r *Register) LoadFailureCount() int32 {
+	return atomic.LoadInt32(&r.loadFailureCount)
+}
+
+// 累加一次加载的耗时, 成功和失败都计入
+func (r *Register) AddLoadTime(d time.Duration) time.Duration {
+	return time.Duration(atomic.AddInt64(&r.totalLoadTime, int64(d)))
+}
+
+func (r *Register) TotalLoadTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&r.totalLoadTime))
+}
+
+// 平均每次加载的耗时
+func (r *Register) AverageLoadTime() time.Duration {
+	total := r.LoadSuccessCount() + r.LoadFailureCount()
+	if total == 0 {
+		return 0
+	}
+	return r.TotalLoadTime() / time.Duration(total)
+}
+
+// 累加成本变化, 写入时为正, 移出缓存时为负
+func (r *Register) AddCost(delta int64) int64 {
+	return atomic.AddInt64(&r.totalCost, delta)
+}
+
+func (r *Register) TotalCost() int64 {
+	return atomic.LoadInt64(&r.totalCost)
+}
+
+// 候选者被准入主区域
+func (r *Register) IncrAdmitCount() int32 {
+	return atomic.AddInt32(&r.admitCount, 1)
+}
+
+func (r *Register) AdmitCount() int32 {
+	return atomic.LoadInt32(&r.admitCount)
+}
+
+// 候选者被拒绝, 直接剔除
+func (r *Register) IncrRejectCount() int32 {
+	return atomic.AddInt32(&r.rejectCount, 1)
+}
+
+func (r *Register) RejectCount() int32 {
+	return atomic.LoadInt32(&r.rejectCount)
+}
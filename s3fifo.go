@@ -0,0 +1,149 @@
+package localcache
+
+import (
+	"container/list"
+	"sync/atomic"
+)
+
+const (
+	smallPercent = 10 // 小队列占总容量的比例
+	s3MaxFreq    = 3  // 访问频率上限, 2 位
+)
+
+// 元素所在的队列
+const (
+	s3Small = iota // 新写入的元素
+	s3Main         // 在小队列中被访问过, 或者从幽灵队列回来的元素
+	s3Ghost        // 从小队列剔除的幽灵元素, 只保存 key
+)
+
+// S3-FIFO 淘汰策略
+// 三个队列都是 FIFO, 命中时只原子地增加访问频率, 读取只需要读锁;
+// 小队列过滤掉只访问一次的元素, 访问过的元素进入主队列, 主队列中有访问频率的元素降低频率后重新入队
+type s3FIFO struct {
+	capacity int
+	lists    [3]*list.List
+	nodes    map[interface{}]*list.Element
+}
+
+type s3Node struct {
+	key   interface{}
+	freq  int32
+	queue int
+}
+
+func newS3FIFO(builder *CacheBuilder) *s3FIFO {
+	p := &s3FIFO{
+		capacity: builder.capacity,
+		nodes:    make(map[interface{}]*list.Element),
+	}
+	for i := range p.lists {
+		p.lists[i] = list.New()
+	}
+	return p
+}
+
+func (p *s3FIFO) ConcurrentAccess() {}
+
+func (p *s3FIFO) OnInsert(key interface{}) {
+	elem, ok := p.nodes[key]
+	if !ok {
+		p.nodes[key] = p.lists[s3Small].PushFront(&s3Node{key: key, queue: s3Small})
+		return
+	}
+	if elem.Value.(*s3Node).queue != s3Ghost {
+		p.OnAccess(key)
+		return
+	}
+	p.moveTo(elem, s3Main)
+}
+
+func (p *s3FIFO) OnAccess(key interface{}) {
+	elem, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	node := elem.Value.(*s3Node)
+	if node.queue == s3Ghost {
+		return
+	}
+	for {
+		freq := atomic.LoadInt32(&node.freq)
+		if freq >= s3MaxFreq || atomic.CompareAndSwapInt32(&node.freq, freq, freq+1) {
+			return
+		}
+	}
+}
+
+func (p *s3FIFO) OnRemove(key interface{}) {
+	elem, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	if elem.Value.(*s3Node).queue != s3Ghost {
+		p.remove(elem)
+	}
+}
+
+// 小队列超出比例时从小队列剔除, 否则从主队列剔除
+func (p *s3FIFO) Victim() (interface{}, bool) {
+	small, main := p.lists[s3Small], p.lists[s3Main]
+	for small.Len()+main.Len() > 0 {
+		if small.Len() > 0 && (small.Len() >= p.smallCap() || main.Len() == 0) {
+			elem := small.Back()
+			node := elem.Value.(*s3Node)
+			if atomic.LoadInt32(&node.freq) > 0 {
+				p.moveTo(elem, s3Main)
+				continue
+			}
+
+			p.moveTo(elem, s3Ghost)
+			ghost := p.lists[s3Ghost]
+			for ghost.Len() > 0 && ghost.Len() > p.limit()-p.smallCap() {
+				p.remove(ghost.Back())
+			}
+			return node.key, true
+		}
+
+		elem := main.Back()
+		node := elem.Value.(*s3Node)
+		if atomic.LoadInt32(&node.freq) > 0 {
+			atomic.AddInt32(&node.freq, -1)
+			main.MoveToFront(elem)
+			continue
+		}
+		p.remove(elem)
+		return node.key, true
+	}
+	return nil, false
+}
+
+// 小队列的目标大小
+func (p *s3FIFO) smallCap() int {
+	if c := p.limit() * smallPercent / 100; c > 1 {
+		return c
+	}
+	return 1
+}
+
+// 容量, 没有设置容量时以当前常驻元素的数量为准
+func (p *s3FIFO) limit() int {
+	if p.capacity > 0 {
+		return p.capacity
+	}
+	return p.lists[s3Small].Len() + p.lists[s3Main].Len()
+}
+
+func (p *s3FIFO) remove(elem *list.Element) {
+	node := elem.Value.(*s3Node)
+	p.lists[node.queue].Remove(elem)
+	delete(p.nodes, node.key)
+}
+
+// 把元素移动到另一个队列的头部
+func (p *s3FIFO) moveTo(elem *list.Element, queue int) {
+	node := elem.Value.(*s3Node)
+	p.lists[node.queue].Remove(elem)
+	node.queue = queue
+	p.nodes[node.key] = p.lists[queue].PushFront(node)
+}
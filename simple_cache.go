@@ -1,132 +1,238 @@
 package localcache
 
 import (
-	"sync"
+	"container/list"
+	"math/rand"
 	"time"
 )
 
+// SIMPLE 缓存超出容量或总成本时的处理方式
+type OverflowStrategy int
+
+const (
+	OverflowFIFO   OverflowStrategy = iota // 剔除最早写入的元素, 默认
+	OverflowRandom                         // 随机剔除
+	OverflowReject                         // 拒绝写入新元素, 返回 ErrCacheFull
+	OverflowGrow                           // 容量翻倍, 不限制元素数量和总成本
+)
+
 type SimpleCache struct {
 	basicCache
 	threshold int // the threshold of map capacity
-	items    map[interface{}]*Item
+	items     map[interface{}]*Item
+	cost      int64 // 当前总成本
+	strategy  OverflowStrategy
+	order     *list.List    // 写入顺序, 用于 FIFO 剔除
+	keys      []interface{} // 所有 key, 用于随机剔除
 }
 
 type Item struct {
-	value      interface{}
-	mu         sync.RWMutex
-	expiration *time.Time
+	itemMeta
+	value interface{}
+	elem  *list.Element // 在 order 中的位置
+	index int           // 在 keys 中的位置
 }
 
-func (c *SimpleCache) Set(key, value interface{}) error {
-	var err error
-	if c.serializeFunc != nil {
-		value, err = c.serializeFunc(value)
-		if err != nil {
-			return err
-		}
-	}
-	err = c.setValue(key, value)
+func (c *SimpleCache) setValue(key, value interface{}, meta itemMeta) ([]evictedItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if c.addCallback != nil {
-		c.addCallback(key, value)
-	}
-	return err
+	return c.put(key, value, meta)
 }
 
-func (c *SimpleCache) setValue(key, value interface{}) error {
+// 调用方需持有锁
+func (c *SimpleCache) put(key, value interface{}, meta itemMeta) ([]evictedItem, error) {
+	var evicted []evictedItem
+	if c.strategy == OverflowReject && !c.fits(key, meta.cost) {
+		// 先清理已经失效的元素, 仍然放不下时拒绝
+		evicted = c.deleteDead(time.Now())
+		if !c.fits(key, meta.cost) {
+			return evicted, ErrCacheFull
+		}
+	}
+
 	item, ok := c.items[key]
-	if !ok {
+	if ok {
+		evicted = append(evicted, evictedItem{key, item.value, EvictReplaced, item.cost})
+		c.cost -= item.cost
+		// 覆盖写入也算最新写入, 保证刚写入的 key 最后剔除
+		if c.strategy == OverflowFIFO {
+			c.order.MoveToBack(item.elem)
+		}
+	} else {
 		item = &Item{}
 		c.items[key] = item
+		c.track(key, item)
 
 		// if count of key exceed threshold and expand the capacity
-		if c.KeyCount() > c.threshold {
+		if c.strategy == OverflowGrow && len(c.items) > c.threshold {
 			c.expandCapacity()
 		}
 	}
 
-	// lock resource instead of  map
-	item.mu.Lock()
-	defer item.mu.Unlock()
-
 	item.value = value
-	if c.basicCache.duration != nil {
-		item.SetExpire(*c.duration)
+	item.itemMeta = meta
+	c.cost += meta.cost
+
+	return append(evicted, c.evictItems(key)...), nil
+}
+
+// 获取数据的私有方法, 命中时只需要读锁
+func (c *SimpleCache) getValue(key interface{}, now time.Time) (interface{}, itemMeta, error) {
+	c.mu.RLock()
+	item, ok := c.items[key]
+	if !ok {
+		c.mu.RUnlock()
+		return nil, itemMeta{}, ErrNotFound
 	}
+	if !item.isDead(now) {
+		value, meta := item.value, item.itemMeta
+		c.mu.RUnlock()
+		return value, meta, nil
+	}
+	c.mu.RUnlock()
+
+	// 需要删除已经失效的元素, 换成写锁后重新查找, 期间可能已经被覆盖或删除
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
+	return c.get(key, now)
+}
+
+// 调用方需持有锁
+func (c *SimpleCache) get(key interface{}, now time.Time) (interface{}, itemMeta, error) {
+	item, ok := c.items[key]
+	if !ok {
+		return nil, itemMeta{}, ErrNotFound
+	}
+
+	// 校验是否已经过期
+	if item.isDead(now) {
+		c.removeItem(key, item)
+	}
+	return item.value, item.itemMeta, nil
+}
+
+func (c *SimpleCache) Remove(key interface{}) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+
+	c.mu.Lock()
+	item, ok := c.remove(key)
+	c.mu.Unlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+	c.notify(item)
 	return nil
 }
 
-func (c *Item) SetExpire(duration time.Duration) {
-	t := time.Now().Add(duration)
-	c.expiration = &t
+// 调用方需持有读锁
+func (c *SimpleCache) getShared(key interface{}, now time.Time) (interface{}, itemMeta, bool) {
+	item, ok := c.items[key]
+	if !ok {
+		return nil, itemMeta{}, false
+	}
+	if item.isDead(now) {
+		return nil, itemMeta{}, true
+	}
+	return item.value, item.itemMeta, false
 }
 
-func (c *SimpleCache) Get(key interface{}) (interface{}, error) {
-	value, err := c.getValue(key)
-	if err != nil {
-		return nil, err
+// 调用方需持有锁
+func (c *SimpleCache) peek(key interface{}) (interface{}, itemMeta, bool) {
+	item, ok := c.items[key]
+	if !ok {
+		return nil, itemMeta{}, false
 	}
+	return item.value, item.itemMeta, true
+}
 
-	if c.deserializeFunc != nil {
-		value, _ = c.basicCache.deserializeFunc(value)
+// 调用方需持有锁
+func (c *SimpleCache) remove(key interface{}) (evictedItem, bool) {
+	item, ok := c.items[key]
+	if !ok {
+		return evictedItem{}, false
 	}
+	c.removeItem(key, item)
+	return evictedItem{key, item.value, EvictRemoved, item.cost}, true
+}
 
-	if c.flight {
-		if value != nil {
-			(*c.register).IncrHicCount()
-		} else {
-			(*c.register).IncrMissCount()
+// 调用方需持有锁
+func (c *SimpleCache) removeItem(key interface{}, item *Item) {
+	delete(c.items, key)
+	c.cost -= item.cost
+
+	switch c.strategy {
+	case OverflowFIFO:
+		c.order.Remove(item.elem)
+	case OverflowRandom:
+		last := len(c.keys) - 1
+		if item.index != last {
+			c.keys[item.index] = c.keys[last]
+			c.items[c.keys[item.index]].index = item.index
 		}
+		c.keys[last] = nil
+		c.keys = c.keys[:last]
 	}
+}
 
-	return value, nil
+// 记录新元素的写入顺序或位置, 调用方需持有锁
+func (c *SimpleCache) track(key interface{}, item *Item) {
+	switch c.strategy {
+	case OverflowFIFO:
+		item.elem = c.order.PushBack(key)
+	case OverflowRandom:
+		item.index = len(c.keys)
+		c.keys = append(c.keys, key)
+	}
 }
 
-// 获取数据的私有方法
-func (c *SimpleCache) getValue(key interface{}) (interface{}, error) {
-	item, ok := c.items[key];
-	if ok {
-		item.mu.Lock()
-		defer item.mu.Unlock()
+// 判断写入后是否仍在容量和总成本之内, 调用方需持有锁
+func (c *SimpleCache) fits(key interface{}, cost int64) bool {
+	count, total := len(c.items)+1, c.cost+cost
+	if item, ok := c.items[key]; ok {
+		count, total = len(c.items), total-item.cost
+	}
+	return !c.overflow(count, total)
+}
 
-		var value interface{}
+// 按照剔除策略剔除超过容量或总成本的数据, 刚写入的 key 最后剔除, 调用方需持有锁
+func (c *SimpleCache) evictItems(key interface{}) []evictedItem {
+	if c.strategy != OverflowFIFO && c.strategy != OverflowRandom {
+		return nil
+	}
 
-		// 校验是否已经过期
-		if item.IsExpire(time.Now()) {
-			delete(c.items, key)
-			value = nil
-			// 执行过期策略
-			c.expireFunc()
+	var evicted []evictedItem
+	for c.overflow(len(c.items), c.cost) && len(c.items) > 0 {
+		var victim interface{}
+		if c.strategy == OverflowFIFO {
+			victim = c.order.Front().Value
 		} else {
-			value = item.value
+			i := rand.Intn(len(c.keys))
+			if c.keys[i] == key && len(c.keys) > 1 {
+				i = (i + 1) % len(c.keys)
+			}
+			victim = c.keys[i]
 		}
 
-		return value, nil
-	} else {
-		return nil, nil
-	}
-}
-
-func (c *SimpleCache) Remove(key interface{}) error {
-	item, ok := c.items[key]
-	if ok {
-		item.mu.Lock()
-		delete(c.items, key)
-		item.mu.Unlock()
-		item = nil
+		item := c.items[victim]
+		c.removeItem(victim, item)
+		evicted = append(evicted, evictedItem{victim, item.value, EvictCapacity, item.cost})
 	}
-	return nil
+	return evicted
 }
 
 func (c *SimpleCache) GetAll() map[interface{}]interface{} {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
+	now := time.Now()
 	items := make(map[interface{}]interface{}, len(c.items))
-
 	for k, item := range c.items {
-		if c.Has(k) {
+		if !item.IsExpire(now) && !isNegative(item.value) {
 			items[k] = item.value
 		}
 	}
@@ -134,32 +240,69 @@ func (c *SimpleCache) GetAll() map[interface{}]interface{} {
 }
 
 func (c *SimpleCache) KeyCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return len(c.items)
 }
 
 func (c *SimpleCache) Has(key interface{}) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	item, ok := c.items[key]
 	if !ok {
 		return false
 	}
-	return !item.IsExpire(time.Now())
+	return !item.IsExpire(time.Now()) && !isNegative(item.value)
 }
 
-// 判断是否超时
-func (item *Item) IsExpire(now time.Time) bool {
-	if item.expiration == nil {
-		return false
+// 删除所有过期元素
+func (c *SimpleCache) deleteExpired(now time.Time) []evictedItem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.deleteDead(now)
+}
+
+// 调用方需持有锁
+func (c *SimpleCache) deleteDead(now time.Time) []evictedItem {
+	var evicted []evictedItem
+	for k, item := range c.items {
+		if item.isDead(now) {
+			c.removeItem(k, item)
+			evicted = append(evicted, evictedItem{k, item.value, EvictExpired, item.cost})
+		}
 	}
+	return evicted
+}
 
-	if &now == nil {
-		return false
+// 清空所有元素
+func (c *SimpleCache) clear() []evictedItem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	evicted := make([]evictedItem, 0, len(c.items))
+	for k, item := range c.items {
+		evicted = append(evicted, evictedItem{k, item.value, EvictCleared, item.cost})
 	}
-	return item.expiration.Before(now)
+	c.init()
+	return evicted
 }
 
-// expand map capacity
+func (c *SimpleCache) totalCost() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cost
+}
+
+// expand map capacity, 只用于 OverflowGrow, 调用方需持有锁
 func (c *SimpleCache) expandCapacity() {
 	newCapacity := c.capacity << 1
+	if newCapacity == 0 {
+		newCapacity = 1
+	}
 	c.capacity = newCapacity
 	c.calculateThreshold()
 
@@ -167,24 +310,27 @@ func (c *SimpleCache) expandCapacity() {
 	for key, value := range c.items {
 		newMap[key] = value
 	}
-	c.mu.Lock()
 	c.items = newMap
-	c.mu.Unlock()
 }
 
 func newSimpleCache(builder *CacheBuilder) *SimpleCache {
-	cache := &SimpleCache{}
-	buildCache(&cache.basicCache, builder)
+	cache := &SimpleCache{strategy: builder.overflow}
+	buildCache(&cache.basicCache, builder, cache)
+	cache.shared = cache
 
 	cache.init()
+	cache.startJanitor()
 	return cache
 }
 
 func (c *SimpleCache) init() {
 	c.items = make(map[interface{}]*Item, c.capacity)
+	c.cost = 0
+	c.order = list.New()
+	c.keys = nil
 	c.calculateThreshold()
 }
 
 func (c *SimpleCache) calculateThreshold() {
 	c.threshold = c.capacity * 3 / 4 // 0.75
-}
\ No newline at end of file
+}
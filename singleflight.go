@@ -0,0 +1,102 @@
+package localcache
+
+import (
+	"context"
+	"sync"
+)
+
+// 正在进行中的一次加载
+type call struct {
+	done    chan struct{} // 加载完成后关闭
+	value   interface{}
+	err     error
+	waiters int                // 等待结果的调用方数量
+	cancel  context.CancelFunc // 取消加载, 后台加载为 nil
+}
+
+// 合并同一个 key 的并发加载, 只有第一个调用方真正执行
+type loadGroup struct {
+	mu    sync.Mutex
+	calls map[interface{}]*call
+}
+
+// 加载在单独的 goroutine 中执行, 每个调用方只在自己的 ctx 结束时提前返回 ctx.Err()
+// fn 收到的 ctx 保留第一个调用方 ctx 中的值, 所有调用方都放弃等待后被取消
+func (g *loadGroup) do(ctx context.Context, key interface{}, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	c, ok := g.lookup(key)
+	if !ok {
+		loadCtx, cancel := context.WithCancel(detach(ctx))
+		c = g.add(key)
+		c.cancel = cancel
+		go g.finish(key, c, func() (interface{}, error) {
+			return fn(loadCtx)
+		})
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		g.leave(key, c)
+		return nil, ctx.Err()
+	}
+}
+
+// 在后台执行, 同一个 key 已经在执行时直接返回, 不会被取消
+func (g *loadGroup) doAsync(key interface{}, fn func() (interface{}, error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.lookup(key); ok {
+		return
+	}
+	go g.finish(key, g.add(key), fn)
+}
+
+// 调用方需持有锁
+func (g *loadGroup) lookup(key interface{}) (*call, bool) {
+	c, ok := g.calls[key]
+	return c, ok
+}
+
+// 登记一次执行, 调用方需持有锁
+func (g *loadGroup) add(key interface{}) *call {
+	if g.calls == nil {
+		g.calls = make(map[interface{}]*call)
+	}
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	return c
+}
+
+// 调用方放弃等待, 最后一个离开时取消加载, 之后的调用方重新开始加载
+func (g *loadGroup) leave(key interface{}, c *call) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c.waiters--
+	if c.waiters > 0 || c.cancel == nil {
+		return
+	}
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	c.cancel()
+}
+
+func (g *loadGroup) finish(key interface{}, c *call, fn func() (interface{}, error)) {
+	c.value, c.err = fn()
+
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+	close(c.done)
+}
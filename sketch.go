@@ -0,0 +1,120 @@
+package localcache
+
+const (
+	sketchDepth   = 4  // 每个 key 对应的计数器个数
+	sketchMaxFreq = 15 // 计数器上限, 4 位
+)
+
+// count-min sketch 频率估计器, 前面加一层 doorkeeper 布隆过滤器
+// 只出现一次的 key 只占用 doorkeeper, 不会进入 sketch
+// 累计次数达到 sampleSize 后所有计数减半, 让旧的热点逐渐冷却
+type frequencySketch struct {
+	table      [sketchDepth][]uint8
+	doorkeeper []uint64 // 布隆过滤器的位图
+	mask       uint64
+	additions  int // 上次衰减之后的累计次数
+	sampleSize int
+}
+
+func newFrequencySketch(capacity int) *frequencySketch {
+	width := 16
+	for width < capacity {
+		width <<= 1
+	}
+
+	s := &frequencySketch{
+		doorkeeper: make([]uint64, width/16),
+		mask:       uint64(width - 1),
+		sampleSize: 10 * width,
+	}
+	for i := range s.table {
+		s.table[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// 记录一次出现
+func (s *frequencySketch) increment(key interface{}) {
+	h1, h2 := sketchHash(key)
+	if !s.admitDoorkeeper(h1, h2) {
+		return
+	}
+
+	for i := uint64(0); i < sketchDepth; i++ {
+		idx := (h1 + i*h2) & s.mask
+		if s.table[i][idx] < sketchMaxFreq {
+			s.table[i][idx]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+// 估计出现的次数, 取所有计数器的最小值
+func (s *frequencySketch) estimate(key interface{}) int {
+	h1, h2 := sketchHash(key)
+	freq := uint8(sketchMaxFreq)
+	for i := uint64(0); i < sketchDepth; i++ {
+		idx := (h1 + i*h2) & s.mask
+		if s.table[i][idx] < freq {
+			freq = s.table[i][idx]
+		}
+	}
+
+	if s.inDoorkeeper(h1, h2) {
+		return int(freq) + 1
+	}
+	return int(freq)
+}
+
+// 所有计数减半并清空 doorkeeper
+func (s *frequencySketch) reset() {
+	for i := range s.table {
+		for j := range s.table[i] {
+			s.table[i][j] >>= 1
+		}
+	}
+	for i := range s.doorkeeper {
+		s.doorkeeper[i] = 0
+	}
+	s.additions /= 2
+}
+
+// 放入 doorkeeper, 已经存在时返回 true
+func (s *frequencySketch) admitDoorkeeper(h1, h2 uint64) bool {
+	if s.inDoorkeeper(h1, h2) {
+		return true
+	}
+
+	bits := uint64(len(s.doorkeeper) * 64)
+	for i := uint64(0); i < 2; i++ {
+		idx := (h1 + (i+sketchDepth)*h2) % bits
+		s.doorkeeper[idx/64] |= 1 << (idx % 64)
+	}
+	return false
+}
+
+func (s *frequencySketch) inDoorkeeper(h1, h2 uint64) bool {
+	bits := uint64(len(s.doorkeeper) * 64)
+	for i := uint64(0); i < 2; i++ {
+		idx := (h1 + (i+sketchDepth)*h2) % bits
+		if s.doorkeeper[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// 由 key 的 hash 派生出两个 hash, 其他的 hash 通过 h1 + i*h2 得到
+func sketchHash(key interface{}) (uint64, uint64) {
+	x := uint64(hash(key))
+	// splitmix64
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	return x & 0xffffffff, (x >> 32) | 1
+}
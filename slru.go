@@ -0,0 +1,97 @@
+package localcache
+
+import "container/list"
+
+const defaultProtectedRatio = 0.8 // 保护段占容量的比例
+
+// SLRU 淘汰策略
+// 新元素进入试用段, 在试用段中再次被访问后晋升到保护段,
+// 保护段超出比例时尾部的元素降级回试用段, 剔除总是优先从试用段的尾部开始
+type slru struct {
+	capacity       int
+	protectedRatio float64
+	probation      *list.List
+	protected      *list.List
+	nodes          map[interface{}]*list.Element
+}
+
+type slruNode struct {
+	key       interface{}
+	protected bool
+}
+
+func newSLRU(builder *CacheBuilder) *slru {
+	p := &slru{
+		capacity:       builder.capacity,
+		protectedRatio: defaultProtectedRatio,
+		probation:      list.New(),
+		protected:      list.New(),
+		nodes:          make(map[interface{}]*list.Element),
+	}
+	if builder.protectedRatio > 0 && builder.protectedRatio < 1 {
+		p.protectedRatio = builder.protectedRatio
+	}
+	return p
+}
+
+func (p *slru) OnInsert(key interface{}) {
+	if _, ok := p.nodes[key]; ok {
+		p.OnAccess(key)
+		return
+	}
+	p.nodes[key] = p.probation.PushFront(&slruNode{key: key})
+}
+
+func (p *slru) OnAccess(key interface{}) {
+	elem, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+
+	node := elem.Value.(*slruNode)
+	if node.protected {
+		p.protected.MoveToFront(elem)
+		return
+	}
+
+	p.probation.Remove(elem)
+	node.protected = true
+	p.nodes[key] = p.protected.PushFront(node)
+
+	limit := p.capacity
+	if limit <= 0 {
+		limit = p.probation.Len() + p.protected.Len()
+	}
+	for p.protected.Len() > 1 && p.protected.Len() > int(float64(limit)*p.protectedRatio) {
+		demoted := p.protected.Remove(p.protected.Back()).(*slruNode)
+		demoted.protected = false
+		p.nodes[demoted.key] = p.probation.PushFront(demoted)
+	}
+}
+
+func (p *slru) OnRemove(key interface{}) {
+	elem, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	if elem.Value.(*slruNode).protected {
+		p.protected.Remove(elem)
+	} else {
+		p.probation.Remove(elem)
+	}
+	delete(p.nodes, key)
+}
+
+func (p *slru) Victim() (interface{}, bool) {
+	elem := p.probation.Back()
+	if elem == nil {
+		elem = p.protected.Back()
+	}
+	if elem == nil {
+		return nil, false
+	}
+
+	key := elem.Value.(*slruNode).key
+	p.OnRemove(key)
+	return key, true
+}
@@ -0,0 +1,164 @@
+package localcache
+
+import "container/list"
+
+const (
+	windowPercent    = 1  // 窗口区占总容量的比例
+	protectedPercent = 80 // 保护区占主区域的比例
+)
+
+// 元素所在的区域
+const (
+	segmentWindow = iota
+	segmentProbation
+	segmentProtected
+)
+
+// W-TinyLFU 淘汰策略
+// 新元素先进入窗口 LRU, 窗口溢出的元素作为候选者进入分段 LRU 主区域,
+// 主区域满了以后候选者需要和主区域的受害者比较访问频率, 频率更高才允许进入
+type tinyLFU struct {
+	sketch       *frequencySketch
+	nodes        map[interface{}]*list.Element
+	window       *list.List
+	probation    *list.List // 主区域试用段
+	protected    *list.List // 主区域保护段
+	windowCap    int
+	mainCap      int // 小于等于 0 表示不限制
+	protectedCap int
+	register     *RegisterAccessor // 记录准入和拒绝, 没有开启飞行器时为 nil
+}
+
+type tinyLFUNode struct {
+	key     interface{}
+	segment int
+}
+
+func newTinyLFU(builder *CacheBuilder) *tinyLFU {
+	p := &tinyLFU{
+		sketch:    newFrequencySketch(builder.capacity),
+		nodes:     make(map[interface{}]*list.Element),
+		window:    list.New(),
+		probation: list.New(),
+		protected: list.New(),
+		windowCap: 1,
+	}
+
+	if builder.capacity > 0 {
+		if w := builder.capacity * windowPercent / 100; w > 1 {
+			p.windowCap = w
+		}
+		p.mainCap = builder.capacity - p.windowCap
+		p.protectedCap = p.mainCap * protectedPercent / 100
+	}
+	if builder.flight {
+		p.register = builder.register
+	}
+	return p
+}
+
+func (p *tinyLFU) OnInsert(key interface{}) {
+	p.sketch.increment(key)
+	p.nodes[key] = p.window.PushFront(&tinyLFUNode{key: key, segment: segmentWindow})
+
+	// 主区域还有空间时, 窗口溢出的元素直接进入试用段
+	for p.window.Len() > p.windowCap && (p.mainCap <= 0 || p.mainLen() < p.mainCap) {
+		p.moveTo(p.window.Back(), p.probation, segmentProbation)
+	}
+}
+
+func (p *tinyLFU) OnAccess(key interface{}) {
+	p.sketch.increment(key)
+	elem, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+
+	switch elem.Value.(*tinyLFUNode).segment {
+	case segmentWindow:
+		p.window.MoveToFront(elem)
+	case segmentProbation:
+		// 试用段中再次被访问, 晋升到保护段, 保护段溢出的元素降级回试用段
+		p.moveTo(elem, p.protected, segmentProtected)
+		if p.mainCap > 0 && p.protected.Len() > p.protectedCap {
+			p.moveTo(p.protected.Back(), p.probation, segmentProbation)
+		}
+	case segmentProtected:
+		p.protected.MoveToFront(elem)
+	}
+}
+
+func (p *tinyLFU) OnRemove(key interface{}) {
+	elem, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	p.listOf(elem).Remove(elem)
+	delete(p.nodes, key)
+}
+
+func (p *tinyLFU) Victim() (interface{}, bool) {
+	if p.window.Len() > p.windowCap && p.mainLen() > 0 {
+		candidate := p.window.Back()
+		victim := p.mainVictim()
+
+		candidateKey := candidate.Value.(*tinyLFUNode).key
+		victimKey := victim.Value.(*tinyLFUNode).key
+		if p.sketch.estimate(candidateKey) > p.sketch.estimate(victimKey) {
+			if p.register != nil {
+				(*p.register).IncrAdmitCount()
+			}
+			p.moveTo(candidate, p.probation, segmentProbation)
+			p.OnRemove(victimKey)
+			return victimKey, true
+		}
+
+		if p.register != nil {
+			(*p.register).IncrRejectCount()
+		}
+		p.OnRemove(candidateKey)
+		return candidateKey, true
+	}
+
+	// 只是总成本超出上限, 优先剔除试用段
+	elem := p.mainVictim()
+	if elem == nil {
+		elem = p.window.Back()
+	}
+	if elem == nil {
+		return nil, false
+	}
+	key := elem.Value.(*tinyLFUNode).key
+	p.OnRemove(key)
+	return key, true
+}
+
+// 主区域的受害者, 优先选择试用段的尾部
+func (p *tinyLFU) mainVictim() *list.Element {
+	if elem := p.probation.Back(); elem != nil {
+		return elem
+	}
+	return p.protected.Back()
+}
+
+func (p *tinyLFU) mainLen() int {
+	return p.probation.Len() + p.protected.Len()
+}
+
+func (p *tinyLFU) listOf(elem *list.Element) *list.List {
+	switch elem.Value.(*tinyLFUNode).segment {
+	case segmentWindow:
+		return p.window
+	case segmentProbation:
+		return p.probation
+	}
+	return p.protected
+}
+
+// 把元素移动到另一个区域的头部
+func (p *tinyLFU) moveTo(elem *list.Element, to *list.List, segment int) {
+	node := elem.Value.(*tinyLFUNode)
+	p.listOf(elem).Remove(elem)
+	node.segment = segment
+	p.nodes[node.key] = to.PushFront(node)
+}
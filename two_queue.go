@@ -0,0 +1,125 @@
+package localcache
+
+import "container/list"
+
+const (
+	defaultRecentRatio = 0.25 // A1in 占容量的比例
+	defaultGhostRatio  = 0.5  // A1out 占容量的比例
+)
+
+// 元素所在的队列
+const (
+	queueA1in  = iota // 第一次写入的元素, FIFO
+	queueA1out        // 从 A1in 剔除的幽灵元素, 只保存 key
+	queueAm           // 再次出现的元素, LRU
+)
+
+// 2Q 淘汰策略
+// 新元素先进入 A1in, 在 A1in 中被访问不会调整顺序, 从 A1in 剔除后在 A1out 中保留 key,
+// 只有在 A1out 期间再次写入的元素才会进入 Am, 一次性的扫描不会冲掉 Am 中的热点
+type twoQueue struct {
+	capacity    int
+	recentRatio float64
+	ghostRatio  float64
+	lists       [3]*list.List
+	nodes       map[interface{}]*list.Element
+}
+
+type twoQueueNode struct {
+	key   interface{}
+	queue int
+}
+
+func newTwoQueue(builder *CacheBuilder) *twoQueue {
+	p := &twoQueue{
+		capacity:    builder.capacity,
+		recentRatio: defaultRecentRatio,
+		ghostRatio:  defaultGhostRatio,
+		nodes:       make(map[interface{}]*list.Element),
+	}
+	if builder.recentRatio > 0 && builder.recentRatio < 1 {
+		p.recentRatio = builder.recentRatio
+	}
+	if builder.ghostRatio > 0 {
+		p.ghostRatio = builder.ghostRatio
+	}
+	for i := range p.lists {
+		p.lists[i] = list.New()
+	}
+	return p
+}
+
+func (p *twoQueue) OnInsert(key interface{}) {
+	if elem, ok := p.nodes[key]; ok {
+		if elem.Value.(*twoQueueNode).queue == queueA1out {
+			p.moveTo(elem, queueAm)
+		} else {
+			p.OnAccess(key)
+		}
+		return
+	}
+	p.nodes[key] = p.lists[queueA1in].PushFront(&twoQueueNode{key: key, queue: queueA1in})
+}
+
+func (p *twoQueue) OnAccess(key interface{}) {
+	elem, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	if elem.Value.(*twoQueueNode).queue == queueAm {
+		p.lists[queueAm].MoveToFront(elem)
+	}
+}
+
+func (p *twoQueue) OnRemove(key interface{}) {
+	elem, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	if elem.Value.(*twoQueueNode).queue != queueA1out {
+		p.remove(elem)
+	}
+}
+
+// A1in 超出比例时从 A1in 剔除并记入 A1out, 否则从 Am 剔除
+func (p *twoQueue) Victim() (interface{}, bool) {
+	in, am := p.lists[queueA1in], p.lists[queueAm]
+	resident := in.Len() + am.Len()
+	if resident == 0 {
+		return nil, false
+	}
+
+	limit := p.capacity
+	if limit <= 0 {
+		limit = resident
+	}
+
+	if in.Len() > 0 && (in.Len() > int(float64(limit)*p.recentRatio) || am.Len() == 0) {
+		elem := in.Back()
+		p.moveTo(elem, queueA1out)
+
+		out := p.lists[queueA1out]
+		for out.Len() > 0 && out.Len() > int(float64(limit)*p.ghostRatio) {
+			p.remove(out.Back())
+		}
+		return elem.Value.(*twoQueueNode).key, true
+	}
+
+	elem := am.Back()
+	p.remove(elem)
+	return elem.Value.(*twoQueueNode).key, true
+}
+
+func (p *twoQueue) remove(elem *list.Element) {
+	node := elem.Value.(*twoQueueNode)
+	p.lists[node.queue].Remove(elem)
+	delete(p.nodes, node.key)
+}
+
+// 把元素移动到另一个队列的头部
+func (p *twoQueue) moveTo(elem *list.Element, queue int) {
+	node := elem.Value.(*twoQueueNode)
+	p.lists[node.queue].Remove(elem)
+	node.queue = queue
+	p.nodes[node.key] = p.lists[queue].PushFront(node)
+}